@@ -0,0 +1,138 @@
+package dlog
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// AuditChange logs the fields of the struct that changed between before and
+// after, as "audit: Type: key=old->new ...".  Only the fields tagged with
+// `audit:"key"` are compared, so the fields that are not tagged, such as the
+// secrets, are never logged, including the fields of the nested structs.  The
+// changes in the nested structs are logged as "parent.key=old->new".  If the
+// key in the tag is empty, the field name is used.  Pointers to structs are
+// accepted.  If before and after have different types, or are not structs,
+// the error is logged instead.
+func (l *Logger) AuditChange(before, after interface{}) {
+	l = l.orStd()
+	l.Output(2, auditString(before, after))
+}
+
+// AuditChange logs the changed audit fields to the standard logger.
+func AuditChange(before, after interface{}) {
+	std.Output(2, auditString(before, after))
+}
+
+func auditString(before, after interface{}) string {
+	a, b := reflect.ValueOf(before), reflect.ValueOf(after)
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		return fmt.Sprintf("audit: error: type mismatch: %T != %T", before, after)
+	}
+	for a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return fmt.Sprintf("audit: error: nil %T", before)
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	if a.Kind() != reflect.Struct {
+		return fmt.Sprintf("audit: error: not a struct: %T", before)
+	}
+	var changes []string
+	auditFields(&changes, "", a, b)
+	if len(changes) == 0 {
+		return "audit: " + a.Type().String() + ": no changes"
+	}
+	return "audit: " + a.Type().String() + ": " + strings.Join(changes, " ")
+}
+
+// auditFields appends the changes of the tagged fields of the structs a and
+// b to changes.  The nested structs are compared field by field, and their
+// keys are prefixed with the key of the parent field.
+func auditFields(changes *[]string, prefix string, a, b reflect.Value) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, ok := t.Field(i).Tag.Lookup("audit")
+		if !ok {
+			continue
+		}
+		if key == "" {
+			key = t.Field(i).Name
+		}
+		key = prefix + key
+		if sa, sb, ok := auditStructs(a.Field(i), b.Field(i)); ok {
+			auditFields(changes, key+".", sa, sb)
+			continue
+		}
+		if old, new := auditValue(a.Field(i), 0), auditValue(b.Field(i), 0); old != new {
+			*changes = append(*changes, key+"="+old+"->"+new)
+		}
+	}
+}
+
+// auditStructs returns a and b, or the values they point to, if they are
+// structs to be compared field by field.
+func auditStructs(a, b reflect.Value) (reflect.Value, reflect.Value, bool) {
+	for a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return a, b, false
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	if a.Kind() != reflect.Struct {
+		return a, b, false
+	}
+	if _, ok := stringValue(a); ok {
+		return a, b, false
+	}
+	return a, b, true
+}
+
+// auditValue renders v, the structs within v are rendered with their tagged
+// fields only, so that the untagged fields are never logged.
+func auditValue(v reflect.Value, depth int) string {
+	if depth > maxDiffDepth {
+		return "..."
+	}
+	if s, ok := stringValue(v); ok {
+		return s
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return "<nil>"
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "<nil>"
+		}
+		return auditValue(v.Elem(), depth+1)
+	case reflect.Struct:
+		var fields []string
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key, ok := t.Field(i).Tag.Lookup("audit")
+			if !ok {
+				continue
+			}
+			if key == "" {
+				key = t.Field(i).Name
+			}
+			fields = append(fields, key+":"+auditValue(v.Field(i), depth+1))
+		}
+		return "{" + strings.Join(fields, " ") + "}"
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = auditValue(v.Index(i), depth+1)
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			items = append(items, fmt.Sprint(k)+":"+auditValue(v.MapIndex(k), depth+1))
+		}
+		sort.Strings(items)
+		return "map[" + strings.Join(items, " ") + "]"
+	}
+	return fmt.Sprint(v)
+}
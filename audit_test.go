@@ -0,0 +1,88 @@
+package dlog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+)
+
+type auditUser struct {
+	Name     string `audit:"name"`
+	Role     string `audit:""`
+	Password string
+	Tags     []string `audit:"tags"`
+}
+
+type auditAccount struct {
+	ID      int          `audit:"id"`
+	Owner   auditUser    `audit:"owner"`
+	Members []*auditUser `audit:"members"`
+}
+
+func TestLogger_AuditChange(t *testing.T) {
+	t.Parallel()
+	before := auditUser{Name: "bob", Role: "user", Password: "secret", Tags: []string{"a"}}
+	tests := []struct {
+		name         string
+		before       interface{}
+		after        interface{}
+		wantOutputRe string
+	}{
+		{"no changes",
+			before,
+			before,
+			`^audit_test\.go:\d+: audit: dlog\.auditUser: no changes$`,
+		},
+		{"changes",
+			before,
+			auditUser{Name: "alice", Role: "admin", Password: "secret", Tags: []string{"a"}},
+			`^audit_test\.go:\d+: audit: dlog\.auditUser: name=bob->alice Role=user->admin$`,
+		},
+		{"untagged field is not logged",
+			before,
+			auditUser{Name: "bob", Role: "user", Password: "changed", Tags: []string{"a"}},
+			`^audit_test\.go:\d+: audit: dlog\.auditUser: no changes$`,
+		},
+		{"slice",
+			&before,
+			&auditUser{Name: "bob", Role: "user", Tags: []string{"a", "b"}},
+			`^audit_test\.go:\d+: audit: dlog\.auditUser: tags=\[.*\]->\[.*\]$`,
+		},
+		{"nested struct",
+			auditAccount{ID: 1, Owner: auditUser{Name: "bob", Password: "s3cret"}},
+			auditAccount{ID: 1, Owner: auditUser{Name: "alice", Password: "s3cret"}},
+			`^audit_test\.go:\d+: audit: dlog\.auditAccount: owner\.name=bob->alice$`,
+		},
+		{"nested secret changed",
+			auditAccount{Owner: auditUser{Name: "bob", Password: "old"}},
+			auditAccount{Owner: auditUser{Name: "bob", Password: "new"}},
+			`^audit_test\.go:\d+: audit: dlog\.auditAccount: no changes$`,
+		},
+		{"slice of structs",
+			auditAccount{Members: []*auditUser{{Name: "bob", Password: "s3cret"}}},
+			auditAccount{Members: []*auditUser{{Name: "bob", Password: "s3cret"}, {Name: "eve", Password: "s3cret"}}},
+			`^audit_test\.go:\d+: audit: dlog\.auditAccount: members=\[\{name:bob Role: tags:\[\]\}\]->\[\{name:bob Role: tags:\[\]\} \{name:eve Role: tags:\[\]\}\]$`,
+		},
+		{"type mismatch",
+			before,
+			&before,
+			`^audit_test\.go:\d+: audit: error: type mismatch: dlog\.auditUser != \*dlog\.auditUser$`,
+		},
+		{"not a struct",
+			1,
+			2,
+			`^audit_test\.go:\d+: audit: error: not a struct: int$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", log.Lshortfile, false)
+			l.AuditChange(tt.before, tt.after)
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
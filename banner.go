@@ -0,0 +1,69 @@
+package dlog
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// BuildInfo is the build information of the program, logged by Banner.
+type BuildInfo struct {
+	Path      string // main module path
+	Version   string // main module version
+	Commit    string // VCS revision
+	BuildTime string // VCS commit time
+	GoVersion string // Go version used to build the binary
+}
+
+// CurrentBuildInfo returns the build information embedded in the running
+// binary.  Fields that are not available are left empty.  The commit and the
+// build time are only available in the binaries built with Go 1.18 or later.
+func CurrentBuildInfo() BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{}
+	}
+	info := BuildInfo{
+		Path:    bi.Main.Path,
+		Version: bi.Main.Version,
+	}
+	addBuildSettings(&info, bi)
+	return info
+}
+
+// Banner logs the build information as a block of lines, i.e.:
+//
+//	example.com/app
+//	  version: v1.2.3
+//	  commit:  0123abcd
+//	  built:   2023-01-02T15:04:05Z
+//	  go:      go1.20
+//
+// Empty fields are omitted.
+func (l *Logger) Banner(info BuildInfo) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	name := info.Path
+	if name == "" {
+		name = "unknown"
+	}
+	l.Output(2, name)
+	for _, f := range []struct {
+		name, value string
+	}{
+		{"version", info.Version},
+		{"commit", info.Commit},
+		{"built", info.BuildTime},
+		{"go", info.GoVersion},
+	} {
+		if f.value != "" {
+			l.Output(2, fmt.Sprintf("  %-8s %s", f.name+":", f.value))
+		}
+	}
+}
+
+// Banner logs the build information to the standard logger.
+func Banner(info BuildInfo) {
+	std.Banner(info)
+}
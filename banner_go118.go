@@ -0,0 +1,20 @@
+//go:build go1.18
+// +build go1.18
+
+package dlog
+
+import "runtime/debug"
+
+// addBuildSettings fills the fields of info, that are only present in the
+// build information since Go 1.18.
+func addBuildSettings(info *BuildInfo, bi *debug.BuildInfo) {
+	info.GoVersion = bi.GoVersion
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.time":
+			info.BuildTime = s.Value
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !go1.18
+// +build !go1.18
+
+package dlog
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// addBuildSettings fills the Go version, the build information of Go before
+// 1.18 has no VCS settings.
+func addBuildSettings(info *BuildInfo, _ *debug.BuildInfo) {
+	info.GoVersion = runtime.Version()
+}
@@ -0,0 +1,42 @@
+package dlog
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+)
+
+func TestLogger_Banner(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		info BuildInfo
+		want string
+	}{
+		{"full",
+			BuildInfo{Path: "example.com/app", Version: "v1.2.3", Commit: "0123abcd", BuildTime: "2023-01-02T15:04:05Z", GoVersion: "go1.20"},
+			"> example.com/app\n" +
+				">   version: v1.2.3\n" +
+				">   commit:  0123abcd\n" +
+				">   built:   2023-01-02T15:04:05Z\n" +
+				">   go:      go1.20\n",
+		},
+		{"empty", BuildInfo{}, "> unknown\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "> ", 0, false)
+			l.Banner(tt.info)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Banner() output:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentBuildInfo(t *testing.T) {
+	if got := CurrentBuildInfo().GoVersion; got != runtime.Version() {
+		t.Errorf("want go version: %q, got: %q", runtime.Version(), got)
+	}
+}
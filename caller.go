@@ -0,0 +1,60 @@
+package dlog
+
+import (
+	"runtime"
+	"strings"
+)
+
+// SetCallerSkipPackages sets the list of packages (import paths) that are
+// skipped when determining the caller for the file name and line number in
+// the output.  The reported caller is the first frame, starting from the
+// logging call, that is outside of these packages.  This is useful when
+// logging goes through several helper layers.  If no such frame is found, or
+// the list is empty, the caller is reported as usual.
+func (l *Logger) SetCallerSkipPackages(pkgs []string) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(pkgs) == 0 {
+		l.skipPkgs = nil
+		return
+	}
+	l.skipPkgs = make(map[string]bool, len(pkgs))
+	for _, p := range pkgs {
+		l.skipPkgs[p] = true
+	}
+}
+
+// SetCallerSkipPackages sets the list of packages that are skipped when
+// determining the caller for the standard logger output.
+func SetCallerSkipPackages(pkgs []string) {
+	std.SetCallerSkipPackages(pkgs)
+}
+
+// skipFrames returns the number of frames, starting at skip frames up the
+// stack of the caller of skipFrames, that belong to the packages in pkgs.
+func skipFrames(skip int, pkgs map[string]bool) int {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+2, pcs[:]) // +2 for runtime.Callers and skipFrames.
+	frames := runtime.CallersFrames(pcs[:n])
+	for extra := 0; ; extra++ {
+		frame, more := frames.Next()
+		if !pkgs[funcPackage(frame.Function)] {
+			return extra
+		}
+		if !more {
+			return 0
+		}
+	}
+}
+
+// funcPackage returns the import path of the package of the function with
+// the fully qualified name fn, i.e. "example.com/pkg.(*T).Method" ->
+// "example.com/pkg".
+func funcPackage(fn string) string {
+	slash := strings.LastIndexByte(fn, '/') + 1
+	if dot := strings.IndexByte(fn[slash:], '.'); dot >= 0 {
+		return fn[:slash+dot]
+	}
+	return fn
+}
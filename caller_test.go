@@ -0,0 +1,53 @@
+package dlog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/rusq/dlog/internal/wrapper"
+)
+
+func TestLogger_SetCallerSkipPackages(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		pkgs         []string
+		wantOutputRe string
+	}{
+		{"no skip", nil, `^wrapper\.go:\d+: message$`},
+		{"skip wrapper", []string{"github.com/rusq/dlog/internal/wrapper"}, `^caller_test\.go:\d+: message$`},
+		{"skip everything", []string{"github.com/rusq/dlog/internal/wrapper", "github.com/rusq/dlog", "testing", "runtime"}, `^wrapper\.go:\d+: message$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", log.Lshortfile, false)
+			l.SetCallerSkipPackages(tt.pkgs)
+			wrapper.Print(l, "message")
+
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func Test_funcPackage(t *testing.T) {
+	tests := []struct {
+		fn   string
+		want string
+	}{
+		{"main.main", "main"},
+		{"github.com/rusq/dlog.(*Logger).Print", "github.com/rusq/dlog"},
+		{"github.com/rusq/dlog.TestX.func1", "github.com/rusq/dlog"},
+		{"example.com/a.b/pkg.Func", "example.com/a.b/pkg"},
+		{"runtime.goexit", "runtime"},
+	}
+	for _, tt := range tests {
+		if got := funcPackage(tt.fn); got != tt.want {
+			t.Errorf("funcPackage(%q) = %q, want %q", tt.fn, got, tt.want)
+		}
+	}
+}
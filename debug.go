@@ -0,0 +1,67 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// debugCompiled is true if the debug output is compiled in.
+const debugCompiled = true
+
+// IsDebug returns true if the debugging output is enabled.  It does not
+// acquire the logger lock, so it is cheap to call on hot paths.
+func (l *Logger) IsDebug() bool {
+	l = l.orStd()
+	return atomic.LoadInt32(&l.debug) == 1
+}
+
+func (l *Logger) Debug(v ...interface{}) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, fmt.Sprint(v...))
+	}
+}
+
+func (l *Logger) Debugln(v ...interface{}) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, fmt.Sprintln(v...))
+	}
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, fmt.Sprintf(format, v...))
+	}
+}
+
+func Debug(v ...interface{}) {
+	if std.IsDebug() {
+		std.debugOutput(2, fmt.Sprint(v...))
+	}
+}
+
+func Debugf(format string, v ...interface{}) {
+	if std.IsDebug() {
+		std.debugOutput(2, fmt.Sprintf(format, v...))
+	}
+}
+
+func Debugln(v ...interface{}) {
+	if std.IsDebug() {
+		std.debugOutput(2, fmt.Sprintln(v...))
+	}
+}
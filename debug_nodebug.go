@@ -0,0 +1,32 @@
+//go:build nodebug
+// +build nodebug
+
+package dlog
+
+// This file contains the no-op debug functions, that are used when building
+// with the "nodebug" tag.  The compiler inlines them away, so that the debug
+// calls have no cost: the arguments are not formatted and nothing is written,
+// regardless of SetDebug and the DEBUG environment variable.  The debug
+// helpers (DebugJSON, DebugDump, Around, etc.) check IsDebug, and are
+// disabled as well.
+
+// debugCompiled is false, as the debug output is compiled out.  SetDebug
+// leaves the flags alone.
+const debugCompiled = false
+
+// IsDebug always returns false, as the debug output is compiled out.
+func (l *Logger) IsDebug() bool {
+	return false
+}
+
+func (l *Logger) Debug(v ...interface{}) {}
+
+func (l *Logger) Debugln(v ...interface{}) {}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {}
+
+func Debug(v ...interface{}) {}
+
+func Debugf(format string, v ...interface{}) {}
+
+func Debugln(v ...interface{}) {}
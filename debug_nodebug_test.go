@@ -0,0 +1,46 @@
+//go:build nodebug
+// +build nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// Run with: go test -tags nodebug -run NoDebug
+func TestNoDebug(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, true)
+	l.Debug("debug")
+	l.Debugf("%s", "debugf")
+	l.Debugln("debugln")
+	l.DebugJSON("json", 1)
+	l.DebugDump(1, 1)
+	l.DebugDiff("diff", 1, 2)
+	l.DebugBytes("bytes", 1)
+	l.DumpContext(context.Background())
+	l.Debugt("{a}", map[string]interface{}{"a": 1})
+	l.Around("op", func() error { return nil })
+	l.Measure("op", func() error { return nil })
+	if l.IsDebug() {
+		t.Error("IsDebug must be false in the nodebug build")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected debug output in the nodebug build: %q", buf.String())
+	}
+}
+
+func TestNoDebug_SetDebug(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, true)
+	l.SetDebug(true)
+	if l.Flags() != 0 {
+		t.Errorf("SetDebug changed the flags in the nodebug build: %d", l.Flags())
+	}
+	l.Print("message")
+	if want := "message\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
@@ -0,0 +1,304 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogger_SetDebug(t *testing.T) {
+	t.Parallel()
+	type fields struct {
+		Logger *log.Logger
+		debug  bool
+	}
+	type args struct {
+		b bool
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantDebug bool
+		wantFlags int
+	}{
+		{"set debug", fields{Logger: defaultLogger(), debug: false}, args{true}, true, log.LstdFlags + log.Lshortfile},
+		{"reset debug", fields{Logger: defaultLogger(), debug: true}, args{false}, false, log.LstdFlags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Logger{
+				Logger: tt.fields.Logger,
+				debug:  btoi(tt.fields.debug),
+			}
+			l.SetDebug(tt.args.b)
+			if l.IsDebug() != tt.wantDebug {
+				t.Errorf("want debug: %v, got debug: %v", tt.wantDebug, l.IsDebug())
+			}
+			if flags := l.Flags(); flags != tt.wantFlags {
+				t.Errorf("want flags: %v, got flags: %v", tt.wantFlags, flags)
+			}
+		})
+	}
+}
+
+func TestLogger_Debug(t *testing.T) {
+	t.Parallel()
+	type fields struct {
+		Logger *log.Logger
+		debug  bool
+	}
+	type args struct {
+		v []interface{}
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		args         args
+		wantOutputRe string
+	}{
+		{"debug is on",
+			fields{debug: true},
+			args{v: []interface{}{"message1 ", "message2"}},
+			`^.*message1\s+message2`,
+		},
+		{"debug is off",
+			fields{debug: false},
+			args{v: []interface{}{"message1 ", "message2"}},
+			`^$`,
+		},
+		{"debug is on, prefix is set",
+			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: true},
+			args{v: []interface{}{"message1 ", "message2"}},
+			`^testxxx: .*message1\s+message2$`,
+		},
+		{"debug is off, prefix is set",
+			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: false},
+			args{v: []interface{}{"message1 ", "message2"}},
+			`^$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Logger{
+				Logger: tt.fields.Logger,
+				debug:  btoi(tt.fields.debug),
+			}
+			if l.Logger == nil {
+				l.Logger = defaultLogger()
+			}
+			re, err := regexp.Compile(tt.wantOutputRe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, fn := range []func(arg ...interface{}){l.Debug, l.Debugln} {
+				var buf bytes.Buffer
+				l.SetOutput(&buf)
+				fn(tt.args.v...)
+
+				if !re.Match(bytes.TrimSpace(buf.Bytes())) {
+					t.Errorf("output for fn: %d: mismatch: wantRE: %q, got: %q", i, tt.wantOutputRe, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLogger_Debugf(t *testing.T) {
+	t.Parallel()
+	type fields struct {
+		Logger *log.Logger
+		debug  bool
+	}
+	type args struct {
+		format string
+		v      []interface{}
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		args         args
+		wantOutputRe string
+	}{
+		{"debug is on",
+			fields{debug: true},
+			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
+			`^.*debug_test\.go:.*message1\s+message2`,
+		},
+		{"debug is off",
+			fields{debug: false},
+			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
+			`^$`,
+		},
+		{"debug is on, prefix is set",
+			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: true},
+			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
+			`^testxxx: .*debug_test\.go:.*message1\s+message2$`,
+		},
+		{"debug is off, prefix is set",
+			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: false},
+			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
+			`^$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Logger{
+				Logger: tt.fields.Logger,
+			}
+			if l.Logger == nil {
+				l.Logger = defaultLogger()
+			}
+			re, err := regexp.Compile(tt.wantOutputRe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.SetDebug(tt.fields.debug)
+
+			var buf bytes.Buffer
+			l.SetOutput(&buf)
+			l.Debugf(tt.args.format, tt.args.v...)
+
+			if !re.Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func Test_Debugf(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		format string
+		v      []interface{}
+	}
+	tests := []struct {
+		name         string
+		debug        bool
+		args         args
+		wantOutputRe string
+	}{
+		{"debug is on",
+			true,
+			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
+			`^.*debug_test\.go:.*message1\s+message2`,
+		},
+		{"debug is off",
+			false,
+			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
+			`^$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := regexp.Compile(tt.wantOutputRe)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			SetDebug(tt.debug)
+			defer SetDebug(false)
+
+			var buf bytes.Buffer
+			SetOutput(&buf)
+			defer SetOutput(os.Stderr)
+
+			Debugf(tt.args.format, tt.args.v...)
+
+			if !re.Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_SetDebugOutput(t *testing.T) {
+	t.Parallel()
+	var main, dbg bytes.Buffer
+	l := New(&main, "> ", 0, true)
+	l.SetDebugOutput(&dbg)
+
+	l.Print("normal")
+	l.Debug("debug")
+	if want := regexp.MustCompile(`^> debug_test\.go:\d+: normal\n$`); !want.MatchString(main.String()) {
+		t.Errorf("main output mismatch: wantRE: %q, got: %q", want, main.String())
+	}
+	if want := regexp.MustCompile(`^> debug_test\.go:\d+: debug\n$`); !want.MatchString(dbg.String()) {
+		t.Errorf("debug output mismatch: wantRE: %q, got: %q", want, dbg.String())
+	}
+
+	main.Reset()
+	dbg.Reset()
+	l.SetDebugOutput(nil)
+	l.Debug("debug")
+	if dbg.Len() != 0 || main.Len() == 0 {
+		t.Errorf("debug output did not fall back to main output: main: %q, debug: %q", main.String(), dbg.String())
+	}
+}
+
+// byteWriter writes one byte at a time, yielding in between, to provoke
+// interleaving of concurrent writes.
+type byteWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *byteWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		w.mu.Lock()
+		w.buf.WriteByte(b)
+		w.mu.Unlock()
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func TestLogger_SerializeWrites(t *testing.T) {
+	t.Parallel()
+	var w byteWriter
+	l := New(&w, "", 0, true)
+	l.SetFlags(0)
+	l.SetDebugOutput(&w)
+
+	const n = 50
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			l.Print("main output line")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			l.Debug("debug output line")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			l.WriteRaw([]byte("raw output line\n"))
+		}
+	}()
+	wg.Wait()
+
+	re := regexp.MustCompile(`^(main|raw|debug) output line$`)
+	lines := strings.Split(strings.TrimSpace(w.buf.String()), "\n")
+	if len(lines) != 3*n {
+		t.Errorf("want %d lines, got: %d", 3*n, len(lines))
+	}
+	for _, line := range lines {
+		if !re.MatchString(line) {
+			t.Fatalf("interleaved line: %q", line)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package dlog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// deferredOutput holds the lines buffered in the deferred mode.
+type deferredOutput struct {
+	out io.Writer // the actual output
+	buf bytes.Buffer
+}
+
+// DeferredMode enables or disables the deferred mode.  In the deferred mode
+// the output lines are buffered in memory instead of being written.  Once
+// Fatal* or Panic* is called, the buffered lines are written to the output,
+// and the logger switches back to the normal mode.  Flush discards the
+// buffered lines, i.e. on the clean completion of the program, so that the
+// program is quiet on success and verbose on failure.
+//
+// Disabling the deferred mode writes out the buffered lines.  Only the main
+// output is buffered, the debug output set with SetDebugOutput is not.
+func (l *Logger) DeferredMode(enable bool) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if enable == (l.deferred != nil) {
+		return
+	}
+	if enable {
+		l.deferred = &deferredOutput{out: l.Logger.Writer()}
+		l.Logger.SetOutput(&l.deferred.buf)
+		return
+	}
+	l.releaseLocked()
+}
+
+// Flush discards the lines buffered in the deferred mode.
+func (l *Logger) Flush() {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.deferred != nil {
+		l.deferred.buf.Reset()
+	}
+}
+
+// release writes out the lines buffered in the deferred mode and switches
+// the logger back to the normal mode.
+func (l *Logger) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+// releaseLocked is release, that should be called with l.mu held.
+func (l *Logger) releaseLocked() {
+	if l.deferred == nil {
+		return
+	}
+	l.Logger.SetOutput(l.deferred.out)
+	l.deferred.out.Write(l.deferred.buf.Bytes())
+	l.deferred = nil
+}
+
+// DeferredMode enables or disables the deferred mode of the standard logger.
+func DeferredMode(enable bool) {
+	std.DeferredMode(enable)
+}
+
+// Flush discards the lines buffered by the standard logger in the deferred
+// mode.
+func Flush() {
+	std.Flush()
+}
+
+// BufferStd redirects the standard logger output to a memory buffer, and
+// returns the function that ends the buffering: it restores the original
+// output and, if keep is true, writes the buffered lines to it, otherwise
+// they are discarded.  It is intended for tests, to keep the log of the
+// failed tests only:
+//
+//	done := dlog.BufferStd()
+//	defer func() { done(t.Failed()) }()
+//
+// The calls can be nested, as long as the returned functions are called in
+// the reverse order; the lines kept by the inner call go to the buffer of the
+// outer one.  Subsequent calls of the returned function do nothing.
+func BufferStd() func(keep bool) {
+	buf := new(bytes.Buffer)
+	std.mu.Lock()
+	prev := std.writer()
+	std.setOutput(buf)
+	std.mu.Unlock()
+
+	var once sync.Once
+	return func(keep bool) {
+		once.Do(func() {
+			std.mu.Lock()
+			defer std.mu.Unlock()
+			std.setOutput(prev)
+			if keep {
+				prev.Write(buf.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package dlog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogger_DeferredMode(t *testing.T) {
+	t.Parallel()
+	t.Run("flush discards", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(&buf, "", 0, false)
+		l.DeferredMode(true)
+		l.Print("one")
+		l.Print("two")
+		if buf.Len() != 0 {
+			t.Errorf("unexpected output in deferred mode: %q", buf.String())
+		}
+		if l.Writer() != &buf {
+			t.Errorf("Writer() must return the actual output")
+		}
+		l.Flush()
+		l.DeferredMode(false)
+		l.Print("three")
+		if want := "three\n"; buf.String() != want {
+			t.Errorf("want output: %q, got: %q", want, buf.String())
+		}
+	})
+	t.Run("panic writes out", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := New(&buf, "", 0, false)
+		l.DeferredMode(true)
+		l.Print("one")
+		l.Print("two")
+		func() {
+			defer func() { recover() }()
+			l.Panic("boom")
+		}()
+		l.Print("three")
+		if want := "one\ntwo\nboom\nthree\n"; buf.String() != want {
+			t.Errorf("want output: %q, got: %q", want, buf.String())
+		}
+	})
+	t.Run("set output while deferred", func(t *testing.T) {
+		var buf1, buf2 bytes.Buffer
+		l := New(&buf1, "", 0, false)
+		l.DeferredMode(true)
+		l.Print("one")
+		l.SetOutput(&buf2)
+		l.DeferredMode(false)
+		if buf1.Len() != 0 {
+			t.Errorf("unexpected output to the old writer: %q", buf1.String())
+		}
+		if want := "one\n"; buf2.String() != want {
+			t.Errorf("want output: %q, got: %q", want, buf2.String())
+		}
+	})
+}
+
+func TestBufferStd(t *testing.T) {
+	// not parallel, changes the standard logger.
+	defer Reset()
+	var out bytes.Buffer
+	SetOutput(&out)
+	SetFlags(0)
+
+	discard := BufferStd()
+	Print("discarded")
+	discard(false)
+	discard(true) // no-op
+
+	keep := BufferStd()
+	Print("outer")
+	inner := BufferStd()
+	Print("inner kept")
+	inner(true)
+	innerDiscard := BufferStd()
+	Print("inner discarded")
+	innerDiscard(false)
+	if out.Len() != 0 {
+		t.Errorf("unexpected output while buffering: %q", out.String())
+	}
+	keep(true)
+	Print("after")
+
+	if want := "outer\ninner kept\nafter\n"; out.String() != want {
+		t.Errorf("want output: %q, got: %q", want, out.String())
+	}
+	if Writer() != &out {
+		t.Error("output is not restored")
+	}
+}
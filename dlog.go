@@ -3,106 +3,538 @@
 package dlog
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Logger struct {
+	seq uint64 // line sequence number, first for 64-bit alignment of atomics.
+
 	*log.Logger
-	debug bool
-	mu    sync.Mutex
+	debug       int32 // 1 if debug output is enabled, accessed atomically.
+	goid        bool
+	useSeq      bool
+	panicPx     bool
+	closed      bool
+	noSerialize bool            // see SerializeWrites
+	escapeNL    bool            // true if pipeline has the newline escaping
+	keepNL      bool            // see SetWriteTrimNewline
+	dbg         *log.Logger     // debug output, if different from the main output
+	once        sync.Map        // keys of the WarnOnce messages already logged
+	deferred    *deferredOutput // buffered output in the deferred mode
+	skipPkgs    map[string]bool // packages skipped when looking for the caller
+	ops         []op            // operation stack, see Enter
+	opID        uint64          // last operation ID
+	limiter     *rateLimiter    // see SetRateLimit
+	pipeline    []transform     // filters and transforms of the messages
+	tee         *teeOutput      // output copied to the pipes, see Pipe
+	mu          sync.Mutex
+
+	panicFmt func(v ...interface{}) string // see SetPanicFormatter
 }
 
 var std *Logger
 
+// OsExit is the function called by Fatal* to exit the program.  It is
+// os.Exit, and it can be replaced in tests to check the exit code without
+// exiting:
+//
+//	dlog.OsExit = func(code int) { exitCode = code }
+//	defer func() { dlog.OsExit = os.Exit }()
+//
+// It should not be changed while the loggers are in use by other goroutines.
+var OsExit = os.Exit
+
+// StdLogger is the printing subset of the standard library log.Logger method
+// set.  Both *log.Logger and *Logger implement it, so it can be used by the
+// code that accepts either.
+type StdLogger interface {
+	Print(v ...interface{})
+	Printf(format string, v ...interface{})
+	Println(v ...interface{})
+}
+
+// AsStdLogger returns l as StdLogger.
+func AsStdLogger(l *Logger) StdLogger {
+	return l
+}
+
+// PrefixPosition is the position of the logger prefix in the output line.
+type PrefixPosition int
+
+const (
+	// PrefixBefore puts the prefix at the beginning of the line, before the
+	// date, time and file name: "prefix: 2009/01/23 01:23:23 message".
+	// This is the default.
+	PrefixBefore PrefixPosition = iota
+	// PrefixAfter puts the prefix right before the message, after the date,
+	// time and file name: "2009/01/23 01:23:23 prefix: message".
+	PrefixAfter
+)
+
 type key int
 
 var loggerKey key
 
 func init() {
+	std = New(os.Stderr, "", log.LstdFlags, false)
+	Reset()
+}
+
+// Reset restores the standard logger to its initial configuration: output to
+// stderr, no prefix, standard flags, and the debug mode set from the DEBUG
+// environment variable, which is read again.  It is primarily a testing aid
+// for tests that modify the standard logger.
+func Reset() {
 	isDebug := (os.Getenv("DEBUG") != "")
 	flags := log.LstdFlags
 	if isDebug {
 		flags |= log.Lshortfile
 	}
-	std = New(os.Stderr, "", flags, isDebug)
+	std.mu.Lock()
+	std.deferred = nil
+	std.tee = nil
+	std.Logger.SetOutput(os.Stderr)
+	std.Logger.SetPrefix("")
+	std.Logger.SetFlags(flags)
+	std.goid = false
+	std.useSeq = false
+	std.panicPx = false
+	std.closed = false
+	std.dbg = nil
+	std.skipPkgs = nil
+	std.ops = nil
+	std.noSerialize = false
+	std.escapeNL = false
+	std.keepNL = false
+	std.panicFmt = nil
+	std.limiter = nil
+	std.once.Range(func(key, _ interface{}) bool {
+		std.once.Delete(key)
+		return true
+	})
+	std.pipeline = nil
+	std.mu.Unlock()
+	std.SetDebug(isDebug)
 }
 
 func New(out io.Writer, prefix string, flag int, debug bool) *Logger {
-	l := Logger{Logger: log.New(out, prefix, flag), debug: false}
+	l := Logger{Logger: log.New(out, prefix, flag)}
 	l.SetDebug(debug)
 	return &l
 }
 
-func (l *Logger) Debug(v ...interface{}) {
+// NewContext returns a new Context that has logger attached.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// Context returns a new Context, derived from parent, that has the logger
+// attached.  It is a shorthand for NewContext(parent, l).
+func (l *Logger) Context(parent context.Context) context.Context {
+	l = l.orStd()
+	return NewContext(parent, l)
+}
+
+// FromContext returns the Logger value stored in ctx, if any.  If no Logger
+// is present, it returns the standard logger instance.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(loggerKey).(*Logger)
+	if l == nil || !ok {
+		l = std
+	}
+	return l
+}
+
+// HasLogger returns true if ctx has a Logger attached.  Unlike FromContext,
+// it does not fall back to the standard logger.
+func HasLogger(ctx context.Context) bool {
+	l, ok := ctx.Value(loggerKey).(*Logger)
+	return ok && l != nil
+}
+
+// SetDebug sets/resets the debugging output.
+func (l *Logger) SetDebug(b bool) {
+	l = l.orStd()
 	if l.Logger == nil {
 		l.Logger = defaultLogger()
 	}
-	if l.debug {
-		l.Output(2, fmt.Sprint(v...))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setDebug(b)
+}
+
+// setDebug sets/resets the debugging output, l.mu must be held.
+func (l *Logger) setDebug(b bool) {
+	atomic.StoreInt32(&l.debug, btoi(b))
+	if !debugCompiled {
+		// there's no debug output to add the file name for.
+		return
+	}
+	if b {
+		l.Logger.SetFlags(l.Logger.Flags() | log.Lshortfile)
+	} else {
+		l.Logger.SetFlags(l.Logger.Flags() &^ (1 << log.Lshortfile))
 	}
 }
 
-func (l *Logger) Debugln(v ...interface{}) {
+// Close flushes the output, if it has a Flush method, and then closes it, if
+// it is an io.Closer.  Note that the output is closed even if it was passed
+// to New by the caller, i.e. the file.  The exceptions are os.Stdout,
+// os.Stderr and another *Logger, which are shared and are not closed.  The
+// output is closed even if Flush fails, and the first error is returned.  It
+// is safe to call Close more than once, subsequent calls do nothing.  The
+// logger should not be used after Close.
+func (l *Logger) Close() error {
+	l = l.orStd()
+	l.mu.Lock()
+	if l.closed || l.Logger == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	w := l.writer()
+	// flushing and closing may block, i.e. on network, the lock is not
+	// held, so that the logger is not blocked.
+	l.mu.Unlock()
+	var err error
+	if f, ok := w.(interface{ Flush() error }); ok {
+		err = f.Flush()
+	}
+	if _, ok := w.(*Logger); ok || w == os.Stdout || w == os.Stderr {
+		return err
+	}
+	if c, ok := w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// SetPrefixPosition sets the position of the prefix in the output line.  It
+// sets or clears the log.Lmsgprefix flag.
+func (l *Logger) SetPrefixPosition(pos PrefixPosition) {
+	l = l.orStd()
 	if l.Logger == nil {
 		l.Logger = defaultLogger()
 	}
-	if l.debug {
-		l.Output(2, fmt.Sprintln(v...))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if pos == PrefixAfter {
+		l.SetFlags(l.Flags() | log.Lmsgprefix)
+	} else {
+		l.SetFlags(l.Flags() &^ log.Lmsgprefix)
 	}
 }
 
-func (l *Logger) Debugf(format string, v ...interface{}) {
+// SetIncludeGoroutineID enables or disables tagging of each output line with
+// the ID of the calling goroutine, i.e. "[G42] message".  Goroutine IDs are
+// intended for debugging only and should not be relied on in program logic.
+func (l *Logger) SetIncludeGoroutineID(b bool) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.goid = b
+}
+
+// SetIncludeSequence enables or disables prefixing each output line with a
+// sequence number, i.e. "#42 message".  The numbering starts at 1 and is
+// maintained per logger; it helps to detect dropped or reordered lines when
+// log streams are merged.
+func (l *Logger) SetIncludeSequence(b bool) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.useSeq = b
+}
+
+// SetOutput sets the output destination for the logger.
+func (l *Logger) SetOutput(w io.Writer) {
+	l = l.orStd()
 	if l.Logger == nil {
 		l.Logger = defaultLogger()
 	}
-	if l.debug {
-		l.Output(2, fmt.Sprintf(format, v...))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setOutput(w)
+}
+
+// setOutput sets the output destination, l.mu must be held.
+func (l *Logger) setOutput(w io.Writer) {
+	if l.tee != nil {
+		l.tee.setOutput(w)
+		return
+	}
+	if l.deferred != nil {
+		l.deferred.out = w
+		return
 	}
+	l.Logger.SetOutput(w)
 }
 
-// NewContext returns a new Context that has logger attached.
-func NewContext(ctx context.Context, l *Logger) context.Context {
-	return context.WithValue(ctx, loggerKey, l)
+// WithOutput sets the logger output to w, calls fn, and restores the
+// previous output, even if fn panics.
+func (l *Logger) WithOutput(w io.Writer, fn func()) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	prev := l.writer()
+	l.setOutput(w)
+	l.mu.Unlock()
+	defer l.SetOutput(prev)
+	fn()
 }
 
-// FromContext returns the Logger value stored in ctx, if any.  If no Logger
-// is present, it returns the standard logger instance.
-func FromContext(ctx context.Context) *Logger {
-	l, ok := ctx.Value(loggerKey).(*Logger)
-	if l == nil || !ok {
-		l = std
+// Prefix returns the output prefix for the logger.
+func (l *Logger) Prefix() string {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
 	}
-	return l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.Logger.Prefix()
 }
 
-// SetDebug sets/resets the debugging output.
-func (l *Logger) SetDebug(b bool) {
+// SetPrefix sets the output prefix for the logger.  It is safe to call
+// concurrently with logging.
+func (l *Logger) SetPrefix(prefix string) {
+	l = l.orStd()
 	if l.Logger == nil {
 		l.Logger = defaultLogger()
 	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.debug = b
-	if b {
-		l.SetFlags(l.Flags() | log.Lshortfile)
-	} else {
-		l.SetFlags(l.Flags() &^ (1 << log.Lshortfile))
+	l.Logger.SetPrefix(prefix)
+}
+
+// Flags returns the output flags for the logger.
+func (l *Logger) Flags() int {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
 	}
+	return l.Logger.Flags()
 }
 
-// IsDebug returns true if the debugging output is enabled.
-func (l *Logger) IsDebug() bool {
-	return l.debug
+// SetFlags sets the output flags for the logger.
+func (l *Logger) SetFlags(flag int) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.Logger.SetFlags(flag)
+}
+
+// Writer returns the output destination for the logger.
+func (l *Logger) Writer() io.Writer {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.writer()
+}
+
+// writer returns the output destination, l.mu must be held.
+func (l *Logger) writer() io.Writer {
+	if l.tee != nil {
+		return l.tee.writer()
+	}
+	if l.deferred != nil {
+		return l.deferred.out
+	}
+	return l.Logger.Writer()
+}
+
+// SetDebugOutput sets the output destination for the debug messages.  If w
+// is nil, debug messages are written to the logger output, which is the
+// default.
+func (l *Logger) SetDebugOutput(w io.Writer) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w == nil {
+		l.dbg = nil
+		return
+	}
+	l.dbg = log.New(w, "", 0)
+}
+
+// Output writes the output for a logging event, see log.Logger.Output for
+// the description of calldepth.  It decorates s according to the logger
+// settings before passing it to the underlying logger.
+func (l *Logger) Output(calldepth int, s string) error {
+	l = l.orStd()
+	return l.output(kindNormal, calldepth+1, s) // +1 for this frame.
+}
+
+// debugOutput is the Output for the debug messages, it writes to the debug
+// output, if one is set.
+func (l *Logger) debugOutput(calldepth int, s string) error {
+	return l.output(kindDebug, calldepth+1, s) // +1 for this frame.
+}
+
+// criticalOutput is the Output for the Fatal* and Panic* messages, that are
+// not subject to the rate limit.
+func (l *Logger) criticalOutput(calldepth int, s string) error {
+	return l.output(kindCritical, calldepth+1, s) // +1 for this frame.
+}
+
+// outputKind is the kind of the message written by output.
+type outputKind int
+
+const (
+	kindNormal outputKind = iota
+	kindDebug
+	kindCritical
+)
+
+func (l *Logger) output(kind outputKind, calldepth int, s string) error {
+	return l.outputLines(kind, calldepth+1, []string{s}) // +1 for this frame.
+}
+
+// outputLines writes the lines under a single hold of l.mu, so that they are
+// not interleaved with the output of the other goroutines, unless the
+// serialization is disabled by SerializeWrites(false).
+func (l *Logger) outputLines(kind outputKind, calldepth int, lines []string) error {
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			l.mu.Unlock()
+		}
+	}()
+	out := make([]string, 0, len(lines))
+	for _, s := range lines {
+		if len(l.pipeline) > 0 {
+			var ok bool
+			if s, ok = l.apply(s); !ok {
+				continue
+			}
+		}
+		if l.limiter != nil && kind != kindCritical {
+			ok, report := l.limiter.allow(time.Now())
+			if !ok {
+				continue
+			}
+			if report != "" {
+				out = append(out, report)
+			}
+		}
+		if len(l.ops) > 0 {
+			s = strings.TrimSuffix(s, "\n") + l.opsSuffix()
+		}
+		if l.goid {
+			s = "[G" + strconv.FormatUint(goroutineID(), 10) + "] " + s
+		}
+		if l.useSeq {
+			s = "#" + strconv.FormatUint(atomic.AddUint64(&l.seq, 1), 10) + " " + s
+		}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	if len(l.skipPkgs) > 0 && l.Flags()&(log.Lshortfile|log.Llongfile) != 0 {
+		calldepth += skipFrames(calldepth, l.skipPkgs)
+	}
+	dst := l.Logger
+	if kind == kindDebug && l.dbg != nil {
+		dst = l.dbg
+		dst.SetPrefix(l.Logger.Prefix())
+		dst.SetFlags(l.Flags())
+	}
+	if l.noSerialize {
+		l.mu.Unlock()
+		locked = false
+	}
+	for _, s := range out {
+		if err := dst.Output(calldepth+1, s); err != nil { // +1 for this frame.
+			return err
+		}
+	}
+	return nil
+}
+
+// Print calls l.Output to print to the logger.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Print(v ...interface{}) {
+	l = l.orStd()
+	l.Output(2, fmt.Sprint(v...))
+}
+
+// Printf calls l.Output to print to the logger.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l = l.orStd()
+	l.Output(2, fmt.Sprintf(format, v...))
+}
+
+// Println calls l.Output to print to the logger.
+// Arguments are handled in the manner of fmt.Println.
+func (l *Logger) Println(v ...interface{}) {
+	l = l.orStd()
+	l.Output(2, fmt.Sprintln(v...))
+}
+
+// Fatal is equivalent to l.Print() followed by a call to os.Exit(1).
+func (l *Logger) Fatal(v ...interface{}) {
+	l = l.orStd()
+	l.release()
+	l.criticalOutput(2, fmt.Sprint(v...))
+	OsExit(1)
+}
+
+// Fatalf is equivalent to l.Printf() followed by a call to os.Exit(1).
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l = l.orStd()
+	l.release()
+	l.criticalOutput(2, fmt.Sprintf(format, v...))
+	OsExit(1)
+}
+
+// Fatalln is equivalent to l.Println() followed by a call to os.Exit(1).
+func (l *Logger) Fatalln(v ...interface{}) {
+	l = l.orStd()
+	l.release()
+	l.criticalOutput(2, fmt.Sprintln(v...))
+	OsExit(1)
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// header of its stack trace: "goroutine 42 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
 }
 
 // SetOutput sets the output destination for the standard logger.
 func SetOutput(w io.Writer) {
-	std.Logger.SetOutput(w)
+	std.SetOutput(w)
 }
 
 // Flags returns the output flags for the standard logger.
@@ -137,10 +569,82 @@ func IsDebug() bool {
 	return std.IsDebug()
 }
 
+// SetIncludeSequence enables or disables prefixing the standard logger output
+// lines with a sequence number.
+func SetIncludeSequence(b bool) {
+	std.SetIncludeSequence(b)
+}
+
+// Close flushes and closes the output of the standard logger.  It does
+// nothing for the default stderr output.  It is safe to call more than once,
+// so that the main function can defer it:
+//
+//	defer dlog.Close()
+func Close() error {
+	return std.Close()
+}
+
+// SetPanicIncludesPrefix sets whether the panic value of the standard logger
+// Panic* functions is prefixed with the logger prefix.
+func SetPanicIncludesPrefix(b bool) {
+	std.SetPanicIncludesPrefix(b)
+}
+
+// WithOutput sets the standard logger output to w for the duration of fn.
+func WithOutput(w io.Writer, fn func()) {
+	std.WithOutput(w, fn)
+}
+
+// SetDebugOutput sets the output destination for the debug messages of the
+// standard logger.  If w is nil, debug messages go to the standard output.
+func SetDebugOutput(w io.Writer) {
+	std.SetDebugOutput(w)
+}
+
+// SetPrefixPosition sets the position of the prefix in the standard logger
+// output lines.
+func SetPrefixPosition(pos PrefixPosition) {
+	std.SetPrefixPosition(pos)
+}
+
+// SetIncludeGoroutineID enables or disables tagging of the standard logger
+// output lines with the calling goroutine ID.
+func SetIncludeGoroutineID(b bool) {
+	std.SetIncludeGoroutineID(b)
+}
+
+func btoi(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func defaultLogger() *log.Logger {
 	return log.New(os.Stderr, "", log.LstdFlags)
 }
 
+// orStd returns l, or the standard logger if l is nil, so that the methods
+// can be called on an uninitialised *Logger.
+func (l *Logger) orStd() *Logger {
+	if l == nil {
+		return std
+	}
+	return l
+}
+
+// SetWriteTrimNewline sets whether the standard logger Write strips a
+// single trailing newline.
+func SetWriteTrimNewline(b bool) {
+	std.SetWriteTrimNewline(b)
+}
+
+// SetPanicFormatter sets the function that formats the message of the
+// standard logger Panic* functions.
+func SetPanicFormatter(fn func(v ...interface{}) string) {
+	std.SetPanicFormatter(fn)
+}
+
 // Writer returns the output destination for the standard logger.
 func Writer() io.Writer {
 	return std.Writer()
@@ -151,7 +655,7 @@ func Writer() io.Writer {
 // Print calls Output to print to the standard logger.
 // Arguments are handled in the manner of fmt.Print.
 func Print(v ...interface{}) {
-	std.Print(v...)
+	std.Output(2, fmt.Sprint(v...))
 }
 
 // Printf calls Output to print to the standard logger.
@@ -168,41 +672,176 @@ func Println(v ...interface{}) {
 
 // Fatal is equivalent to Print() followed by a call to os.Exit(1).
 func Fatal(v ...interface{}) {
-	std.Output(2, fmt.Sprint(v...))
-	os.Exit(1)
+	std.release()
+	std.criticalOutput(2, fmt.Sprint(v...))
+	OsExit(1)
 }
 
 // Fatalf is equivalent to Printf() followed by a call to os.Exit(1).
 func Fatalf(format string, v ...interface{}) {
-	std.Output(2, fmt.Sprintf(format, v...))
-	os.Exit(1)
+	std.release()
+	std.criticalOutput(2, fmt.Sprintf(format, v...))
+	OsExit(1)
 }
 
 // Fatalln is equivalent to Println() followed by a call to os.Exit(1).
 func Fatalln(v ...interface{}) {
-	std.Output(2, fmt.Sprintln(v...))
-	os.Exit(1)
+	std.release()
+	std.criticalOutput(2, fmt.Sprintln(v...))
+	OsExit(1)
+}
+
+// SetPanicIncludesPrefix sets whether the value passed to panic() by the
+// Panic* methods is prefixed with the logger prefix, the same way as the
+// logged message.  By default the panic value is the raw message.
+func (l *Logger) SetPanicIncludesPrefix(b bool) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.panicPx = b
+}
+
+// SetPanicFormatter sets the function that formats the message of the
+// Panic* methods, i.e. to add the stack trace.  The formatted message is
+// both logged and used as the panic value.  Panic passes its arguments to
+// fn, Panicf and Panicln pass the message formatted with fmt.Sprintf or
+// fmt.Sprintln as the only argument.  If fn is nil, fmt.Sprint is used,
+// which is the default.
+func (l *Logger) SetPanicFormatter(fn func(v ...interface{}) string) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.panicFmt = fn
+}
+
+// panicMessage formats the message of Panic* with the panic formatter.
+func (l *Logger) panicMessage(v ...interface{}) string {
+	l.mu.Lock()
+	fn := l.panicFmt
+	l.mu.Unlock()
+	if fn == nil {
+		return fmt.Sprint(v...)
+	}
+	return fn(v...)
+}
+
+// panicValue returns the value for panic() for the message s.
+func (l *Logger) panicValue(s string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.panicPx {
+		return l.Logger.Prefix() + s
+	}
+	return s
+}
+
+// WriteRaw writes p to the logger output as is, without the prefix, date,
+// time or the trailing newline.  It is intended for relaying lines that are
+// already formatted.  Writes are serialised with the other logger output,
+// unless disabled with SerializeWrites.
+func (l *Logger) WriteRaw(p []byte) (int, error) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	if l.noSerialize {
+		l.mu.Unlock()
+		return l.Logger.Writer().Write(p)
+	}
+	defer l.mu.Unlock()
+	return l.Logger.Writer().Write(p)
+}
+
+// Write implements io.Writer, so that the logger can be the output of
+// another logger or of a library that writes lines to an io.Writer.  Each
+// call is logged as a single line, with the logger prefix and flags.  A
+// single trailing newline of p is stripped, unless disabled with
+// SetWriteTrimNewline.
+func (l *Logger) Write(p []byte) (int, error) {
+	l = l.orStd()
+	l.mu.Lock()
+	keepNL := l.keepNL
+	l.mu.Unlock()
+	s := string(p)
+	if !keepNL && len(s) > 0 && s[len(s)-1] == '\n' {
+		s = s[:len(s)-1]
+	}
+	if err := l.Output(2, s); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetWriteTrimNewline sets whether Write strips a single trailing newline
+// of the written bytes, which is the default.  The log package appends the
+// newline to the line only if it is missing, so with the trimming disabled
+// the line "msg\n" is still logged once, but "msg\n\n" produces an empty
+// line after it, and the suffixes, such as the operation names of Enter,
+// are placed after the newline.
+func (l *Logger) SetWriteTrimNewline(b bool) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keepNL = !b
+}
+
+// SerializeWrites enables or disables the serialisation of writes.  When
+// enabled, which is the default, each output line is written with a single
+// Write call while holding the logger lock, so the lines of the main output,
+// the debug output and WriteRaw are never interleaved, even if they go to the
+// same writer that does not guarantee atomic writes.  Disabling it removes
+// the lock from the write path; the lines written by the main and debug
+// outputs, or by WriteRaw, may then interleave.
+func (l *Logger) SerializeWrites(b bool) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	l.noSerialize = !b
+	l.mu.Unlock()
+	if !b && l.IsDebug() {
+		l.debugOutput(2, "dlog: write serialisation is disabled, concurrent lines may interleave")
+	}
+}
+
+// WarnOnce logs msg only the first time it's called with the key, subsequent
+// calls with the same key are ignored for the lifetime of the logger.  It's
+// intended for notices that should not be repeated, such as deprecation
+// warnings.
+func (l *Logger) WarnOnce(key, msg string) {
+	l = l.orStd()
+	if _, loaded := l.once.LoadOrStore(key, struct{}{}); !loaded {
+		l.Output(2, msg)
+	}
 }
 
 // Panic is equivalent to Print() followed by a call to panic().
 func (l *Logger) Panic(v ...interface{}) {
-	s := fmt.Sprint(v...)
-	l.Output(2, s)
-	panic(s)
+	l = l.orStd()
+	l.release()
+	s := l.panicMessage(v...)
+	l.criticalOutput(2, s)
+	panic(l.panicValue(s))
 }
 
 // Panicf is equivalent to Printf() followed by a call to panic().
 func (l *Logger) Panicf(format string, v ...interface{}) {
-	s := fmt.Sprintf(format, v...)
-	l.Output(2, s)
-	panic(s)
+	l = l.orStd()
+	l.release()
+	s := l.panicMessage(fmt.Sprintf(format, v...))
+	l.criticalOutput(2, s)
+	panic(l.panicValue(s))
 }
 
 // Panicln is equivalent to Println() followed by a call to panic().
 func (l *Logger) Panicln(v ...interface{}) {
-	s := fmt.Sprintln(v...)
-	l.Output(2, s)
-	panic(s)
+	l = l.orStd()
+	l.release()
+	s := l.panicMessage(fmt.Sprintln(v...))
+	l.criticalOutput(2, s)
+	panic(l.panicValue(s))
 }
 
 // Output writes the output for a logging event. The string s contains
@@ -216,35 +855,45 @@ func Output(calldepth int, s string) error {
 	return std.Output(calldepth+1, s) // +1 for this frame.
 }
 
-func Debug(v ...interface{}) {
-	if std.debug {
-		std.Output(2, fmt.Sprint(v...))
-	}
+// SerializeWrites enables or disables the serialisation of the standard
+// logger writes.
+func SerializeWrites(b bool) {
+	std.SerializeWrites(b)
 }
 
-func Debugf(format string, v ...interface{}) {
-	if std.debug {
-		std.Output(2, fmt.Sprintf(format, v...))
-	}
+// WriteRaw writes p to the standard logger output as is.
+func WriteRaw(p []byte) (int, error) {
+	return std.WriteRaw(p)
 }
 
-func Debugln(v ...interface{}) {
-	if std.debug {
-		std.Output(2, fmt.Sprintln(v...))
+// WarnOnce logs msg to the standard logger only the first time it's called
+// with the key.
+func WarnOnce(key, msg string) {
+	if _, loaded := std.once.LoadOrStore(key, struct{}{}); !loaded {
+		std.Output(2, msg)
 	}
 }
 
 // Panic is equivalent to Print() followed by a call to panic().
 func Panic(v ...interface{}) {
-	std.Panic(v...)
+	std.release()
+	s := std.panicMessage(v...)
+	std.criticalOutput(2, s)
+	panic(std.panicValue(s))
 }
 
 // Panicf is equivalent to Printf() followed by a call to panic().
 func Panicf(format string, v ...interface{}) {
-	std.Panicf(format, v...)
+	std.release()
+	s := std.panicMessage(fmt.Sprintf(format, v...))
+	std.criticalOutput(2, s)
+	panic(std.panicValue(s))
 }
 
 // Panicln is equivalent to Println() followed by a call to panic().
 func Panicln(v ...interface{}) {
-	std.Panicln(v...)
+	std.release()
+	s := std.panicMessage(fmt.Sprintln(v...))
+	std.criticalOutput(2, s)
+	panic(std.panicValue(s))
 }
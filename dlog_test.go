@@ -5,290 +5,776 @@ package dlog
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
-	"reflect"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
-func TestLogger_SetDebug(t *testing.T) {
-	t.Parallel()
-	type fields struct {
-		Logger *log.Logger
-		debug  bool
+func TestNewContext(t *testing.T) {
+	var buf strings.Builder
+	l := New(&buf, ">", log.LstdFlags, true)
+	ctx := NewContext(context.Background(), l)
+
+	lFromCtx, ok := ctx.Value(loggerKey).(*Logger)
+	if lFromCtx == nil || !ok {
+		t.Fatal("failed to get the logger from context")
 	}
+
+	const testVal = "TestNewContext"
+	lFromCtx.Println(testVal)
+	if !strings.Contains(buf.String(), testVal) {
+		t.Fatalf("invalid logger: test value %v, not found in output %v", testVal, buf.String())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	custom := New(os.Stdout, ">", log.LstdFlags, true)
 	type args struct {
-		b bool
+		ctx context.Context
 	}
 	tests := []struct {
-		name      string
-		fields    fields
-		args      args
-		wantDebug bool
-		wantFlags int
+		name string
+		args args
+		want *Logger
 	}{
-		{"set debug", fields{Logger: defaultLogger(), debug: false}, args{true}, true, log.LstdFlags + log.Lshortfile},
-		{"reset debug", fields{Logger: defaultLogger(), debug: true}, args{false}, false, log.LstdFlags},
+		{"standard logger", args{context.Background()}, std},
+		{"custom logger", args{NewContext(context.Background(), custom)}, custom},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			l := &Logger{
-				Logger: tt.fields.Logger,
-				debug:  tt.fields.debug,
-			}
-			l.SetDebug(tt.args.b)
-			if l.debug != tt.wantDebug {
-				t.Errorf("want debug: %v, got debug: %v", tt.wantDebug, l.debug)
-			}
-			if flags := l.Flags(); flags != tt.wantFlags {
-				t.Errorf("want flags: %v, got flags: %v", tt.wantFlags, flags)
+			if got := FromContext(tt.args.ctx); !got.Equal(tt.want) {
+				t.Errorf("FromContext() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestLogger_Debug(t *testing.T) {
+func Test_Printf(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		Logger *log.Logger
-		debug  bool
-	}
 	type args struct {
-		v []interface{}
+		format string
+		v      []interface{}
 	}
 	tests := []struct {
 		name         string
-		fields       fields
 		args         args
 		wantOutputRe string
 	}{
 		{"debug is on",
-			fields{debug: true},
-			args{v: []interface{}{"message1 ", "message2"}},
-			`^.*message1\s+message2`,
-		},
-		{"debug is off",
-			fields{debug: false},
-			args{v: []interface{}{"message1 ", "message2"}},
-			`^$`,
-		},
-		{"debug is on, prefix is set",
-			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: true},
-			args{v: []interface{}{"message1 ", "message2"}},
-			`^testxxx: .*message1\s+message2$`,
-		},
-		{"debug is off, prefix is set",
-			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: false},
-			args{v: []interface{}{"message1 ", "message2"}},
-			`^$`,
+			args{format: "printf: %s%s", v: []interface{}{"message1 ", "message2"}},
+			`^.*printf: message1\s+message2`,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			l := &Logger{
-				Logger: tt.fields.Logger,
-				debug:  tt.fields.debug,
-			}
-			if l.Logger == nil {
-				l.Logger = defaultLogger()
-			}
 			re, err := regexp.Compile(tt.wantOutputRe)
 			if err != nil {
 				t.Fatal(err)
 			}
-			for i, fn := range []func(arg ...interface{}){l.Debug, l.Debugln} {
-				var buf bytes.Buffer
-				l.SetOutput(&buf)
-				fn(tt.args.v...)
 
-				if !re.Match(bytes.TrimSpace(buf.Bytes())) {
-					t.Errorf("output for fn: %d: mismatch: wantRE: %q, got: %q", i, tt.wantOutputRe, buf.String())
-				}
+			var buf bytes.Buffer
+			SetOutput(&buf)
+			defer SetOutput(os.Stderr)
+
+			Printf(tt.args.format, tt.args.v...)
+
+			if !re.Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
 			}
 		})
 	}
 }
 
-func TestLogger_Debugf(t *testing.T) {
+func TestLogger_SetIncludeGoroutineID(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		Logger *log.Logger
-		debug  bool
-	}
-	type args struct {
-		format string
-		v      []interface{}
-	}
 	tests := []struct {
 		name         string
-		fields       fields
-		args         args
+		goid         bool
 		wantOutputRe string
 	}{
-		{"debug is on",
-			fields{debug: true},
-			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
-			`^.*dlog_test\.go:.*message1\s+message2`,
-		},
-		{"debug is off",
-			fields{debug: false},
-			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
-			`^$`,
-		},
-		{"debug is on, prefix is set",
-			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: true},
-			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
-			`^testxxx: .*dlog_test\.go:.*message1\s+message2$`,
-		},
-		{"debug is off, prefix is set",
-			fields{Logger: log.New(os.Stderr, "testxxx: ", log.LstdFlags), debug: false},
-			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
-			`^$`,
-		},
+		{"enabled", true, `^\[G\d+\] message$`},
+		{"disabled", false, `^message$`},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			l := &Logger{
-				Logger: tt.fields.Logger,
-			}
-			if l.Logger == nil {
-				l.Logger = defaultLogger()
-			}
-			re, err := regexp.Compile(tt.wantOutputRe)
-			if err != nil {
-				t.Fatal(err)
-			}
-			l.SetDebug(tt.fields.debug)
-
 			var buf bytes.Buffer
-			l.SetOutput(&buf)
-			l.Debugf(tt.args.format, tt.args.v...)
+			l := New(&buf, "", 0, false)
+			l.SetIncludeGoroutineID(tt.goid)
+			l.Print("message")
 
-			if !re.Match(bytes.TrimSpace(buf.Bytes())) {
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
 				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
 			}
 		})
 	}
 }
 
-func TestNewContext(t *testing.T) {
-	var buf strings.Builder
-	l := New(&buf, ">", log.LstdFlags, true)
-	ctx := NewContext(context.Background(), l)
+func Test_goroutineID(t *testing.T) {
+	ids := make(chan uint64, 2)
+	ids <- goroutineID()
+	go func() { ids <- goroutineID() }()
+	a, b := <-ids, <-ids
+	if a == 0 || b == 0 {
+		t.Fatalf("failed to parse goroutine ID: %d, %d", a, b)
+	}
+	if a == b {
+		t.Errorf("expected different goroutine IDs, got %d for both", a)
+	}
+}
 
-	lFromCtx, ok := ctx.Value(loggerKey).(*Logger)
-	if lFromCtx == nil || !ok {
-		t.Fatal("failed to get the logger from context")
+func TestReset(t *testing.T) {
+	if v, ok := os.LookupEnv("DEBUG"); ok {
+		t.Cleanup(func() {
+			os.Setenv("DEBUG", v)
+			Reset()
+		})
+	} else {
+		t.Cleanup(Reset)
 	}
+	os.Unsetenv("DEBUG")
 
-	const testVal = "TestNewContext"
-	lFromCtx.Println(testVal)
-	if !strings.Contains(buf.String(), testVal) {
-		t.Fatalf("invalid logger: test value %v, not found in output %v", testVal, buf.String())
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetPrefix("test: ")
+	SetFlags(0)
+	SetDebug(true)
+	SetIncludeGoroutineID(true)
+
+	Reset()
+	if w := Writer(); w != os.Stderr {
+		t.Errorf("want output: os.Stderr, got: %v", w)
+	}
+	if p := Prefix(); p != "" {
+		t.Errorf("want empty prefix, got: %q", p)
+	}
+	if f := Flags(); f != log.LstdFlags {
+		t.Errorf("want flags: %v, got: %v", log.LstdFlags, f)
+	}
+	if IsDebug() {
+		t.Error("want debug: false, got: true")
+	}
+	if std.goid {
+		t.Error("want goroutine ID disabled")
 	}
 }
 
-func TestFromContext(t *testing.T) {
-	custom := New(os.Stdout, ">", log.LstdFlags, true)
-	type args struct {
-		ctx context.Context
+func TestLogger_AddFilter(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "pfx: ", 0, false)
+	l.AddFilter(regexp.MustCompile(`noisy`))
+	l.AddFilter(regexp.MustCompile(`^chatter`))
+	l.AddFilter(regexp.MustCompile(`pfx`)) // the prefix is not matched
+
+	l.Print("this is noisy")
+	l.Print("chatter from a dependency")
+	l.Print("keep me")
+	l.Print("keep chatter too")
+
+	if want, got := "pfx: keep me\npfx: keep chatter too\n", buf.String(); got != want {
+		t.Errorf("want output: %q, got: %q", want, got)
+	}
+}
+
+func TestLogger_SetIncludeSequence(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	l.SetIncludeSequence(true)
+	l.AddFilter(regexp.MustCompile(`dropped`))
+
+	l.Print("one")
+	l.Print("dropped")
+	l.Print("two")
+	l.SetIncludeGoroutineID(true)
+	l.Print("three")
+
+	want := regexp.MustCompile(`^#1 one\n#2 two\n#3 \[G\d+\] three\n$`)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("output mismatch: wantRE: %q, got: %q", want, buf.String())
+	}
+}
+
+func TestLogger_Context(t *testing.T) {
+	l := New(os.Stdout, ">", log.LstdFlags, false)
+	ctx := l.Context(context.Background())
+	if got := FromContext(ctx); got != l {
+		t.Errorf("FromContext() = %v, want %v", got, l)
+	}
+}
+
+// mutexFlag is the mutex-guarded flag, as the debug flag used to be, for
+// comparison in benchmarks.
+type mutexFlag struct {
+	mu sync.Mutex
+	b  bool
+}
+
+func (f *mutexFlag) get() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.b
+}
+
+func BenchmarkLogger_Debug_disabled(b *testing.B) {
+	l := New(ioutil.Discard, "", log.LstdFlags, false)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug("message")
+		}
+	})
+}
+
+func BenchmarkLogger_IsDebug(b *testing.B) {
+	l := New(ioutil.Discard, "", log.LstdFlags, false)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = l.IsDebug()
+		}
+	})
+}
+
+func BenchmarkMutexFlag(b *testing.B) {
+	var f mutexFlag
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = f.get()
+		}
+	})
+}
+
+func TestLogger_SetPanicIncludesPrefix(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		include   bool
+		wantPanic string
+	}{
+		{"raw message", false, "message"},
+		{"with prefix", true, "pfx: message"},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "pfx: ", 0, false)
+			l.SetPanicIncludesPrefix(tt.include)
+			defer func() {
+				if r := recover(); r != tt.wantPanic {
+					t.Errorf("want panic value: %q, got: %q", tt.wantPanic, r)
+				}
+				if want := "pfx: message\n"; buf.String() != want {
+					t.Errorf("want output: %q, got: %q", want, buf.String())
+				}
+			}()
+			l.Panic("message")
+		})
+	}
+}
+
+func TestLogger_SetPanicFormatter(t *testing.T) {
+	t.Parallel()
+	upper := func(v ...interface{}) string { return strings.ToUpper(fmt.Sprint(v...)) + " [id=42]" }
 	tests := []struct {
-		name string
-		args args
-		want *Logger
+		name      string
+		fn        func(v ...interface{}) string
+		panicFn   func(l *Logger)
+		wantPanic string
 	}{
-		{"standard logger", args{context.Background()}, std},
-		{"custom logger", args{NewContext(context.Background(), custom)}, custom},
+		{"default", nil, func(l *Logger) { l.Panic("message ", 1) }, "message 1"},
+		{"Panic", upper, func(l *Logger) { l.Panic("message ", 1) }, "MESSAGE 1 [id=42]"},
+		{"Panicf", upper, func(l *Logger) { l.Panicf("message %d", 1) }, "MESSAGE 1 [id=42]"},
+		{"Panicln", upper, func(l *Logger) { l.Panicln("message", 1) }, "MESSAGE 1\n [id=42]"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := FromContext(tt.args.ctx); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("FromContext() = %v, want %v", got, tt.want)
-			}
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, false)
+			l.SetPanicFormatter(tt.fn)
+			defer func() {
+				if r := recover(); r != tt.wantPanic {
+					t.Errorf("want panic value: %q, got: %q", tt.wantPanic, r)
+				}
+				if want := tt.wantPanic + "\n"; buf.String() != want {
+					t.Errorf("want output: %q, got: %q", want, buf.String())
+				}
+			}()
+			tt.panicFn(l)
 		})
 	}
 }
 
-func Test_Debugf(t *testing.T) {
+// flushCloser is a buffer that counts flushes and closes.
+type flushCloser struct {
+	bytes.Buffer
+	flushed int
+	closed  int
+}
+
+func (fc *flushCloser) Flush() error { fc.flushed++; return nil }
+func (fc *flushCloser) Close() error { fc.closed++; return nil }
+
+func TestLogger_Close(t *testing.T) {
 	t.Parallel()
-	type args struct {
-		format string
-		v      []interface{}
+	var fc flushCloser
+	l := New(&fc, "", 0, false)
+	for i := 0; i < 2; i++ {
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
 	}
+	if fc.flushed != 1 || fc.closed != 1 {
+		t.Errorf("want one flush and one close, got flushed: %d, closed: %d", fc.flushed, fc.closed)
+	}
+	if err := New(os.Stderr, "", 0, false).Close(); err != nil {
+		t.Errorf("unexpected error closing stderr logger: %s", err)
+	}
+}
+
+// failingFlusher is a flushCloser, that fails to flush.
+type failingFlusher struct {
+	flushCloser
+}
+
+func (ff *failingFlusher) Flush() error { ff.flushed++; return errors.New("flush error") }
+
+func TestLogger_Close_flushError(t *testing.T) {
+	t.Parallel()
+	var ff failingFlusher
+	l := New(&ff, "", 0, false)
+	if err := l.Close(); err == nil || err.Error() != "flush error" {
+		t.Errorf("want flush error, got: %v", err)
+	}
+	if ff.closed != 1 {
+		t.Errorf("want the output closed after the flush error, got closed: %d", ff.closed)
+	}
+}
+
+func TestLogger_Close_loggerOutput(t *testing.T) {
+	t.Parallel()
+	var fc flushCloser
+	parent := New(&fc, "", 0, false)
+	l := New(parent, "", 0, false)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	parent.Print("parent is not closed")
+	if fc.closed != 0 || !strings.Contains(fc.String(), "parent is not closed") {
+		t.Errorf("the parent logger output must not be closed, closed: %d, output: %q", fc.closed, fc.String())
+	}
+}
+
+func TestLogger_WarnOnce(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	for i := 0; i < 3; i++ {
+		l.WarnOnce("old-api", "old API is deprecated")
+		l.WarnOnce("old-flag", "old flag is deprecated")
+	}
+	if want := "old API is deprecated\nold flag is deprecated\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
+
+func TestLogger_SetPrefixPosition(t *testing.T) {
+	t.Parallel()
 	tests := []struct {
 		name         string
-		debug        bool
-		args         args
+		pos          PrefixPosition
 		wantOutputRe string
 	}{
-		{"debug is on",
-			true,
-			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
-			`^.*dlog_test\.go:.*message1\s+message2`,
-		},
-		{"debug is off",
-			false,
-			args{format: "%s%s", v: []interface{}{"message1 ", "message2"}},
-			`^$`,
-		},
+		{"before", PrefixBefore, `^pfx: \d{2}:\d{2}:\d{2} message$`},
+		{"after", PrefixAfter, `^\d{2}:\d{2}:\d{2} pfx: message$`},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			re, err := regexp.Compile(tt.wantOutputRe)
-			if err != nil {
-				t.Fatal(err)
+			var buf bytes.Buffer
+			l := New(&buf, "pfx: ", log.Ltime, false)
+			l.SetPrefixPosition(PrefixAfter) // make sure "before" resets it
+			l.SetPrefixPosition(tt.pos)
+			l.Print("message")
+
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
 			}
+		})
+	}
+}
+
+func TestLogger_WriteRaw(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "pfx: ", log.LstdFlags, false)
+	n, err := l.WriteRaw([]byte("raw line"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len("raw line") {
+		t.Errorf("want n: %d, got: %d", len("raw line"), n)
+	}
+	if want := "raw line"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
 
-			SetDebug(tt.debug)
-			defer SetDebug(false)
+var (
+	_ StdLogger = (*Logger)(nil)
+	_ StdLogger = (*log.Logger)(nil)
+)
 
+func TestAsStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	AsStdLogger(l).Printf("%d", 42)
+	if want := "42\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
+
+func TestLogger_SetPrefix_concurrent(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			l.SetPrefix(strconv.Itoa(i) + ": ")
+			_ = l.Prefix()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			l.Print("message")
+		}
+	}()
+	wg.Wait()
+
+	re := regexp.MustCompile(`^\d+: message$|^message$`)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != n {
+		t.Fatalf("want %d lines, got: %d", n, len(lines))
+	}
+	for _, line := range lines {
+		if !re.MatchString(line) {
+			t.Errorf("malformed line: %q", line)
+		}
+	}
+}
+
+func TestLogger_SetEscapeNewlines(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		escape bool
+		want   string
+	}{
+		{"disabled", false, "a\nb\r\nc\nd\n"},
+		{"enabled", true, `a\nb\r\nc` + "\n" + "d\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
 			var buf bytes.Buffer
-			SetOutput(&buf)
-			defer SetOutput(os.Stderr)
+			l := New(&buf, "", 0, false)
+			l.SetEscapeNewlines(tt.escape)
+			l.Println("a\nb\r\nc")
+			l.Print("d")
+			if buf.String() != tt.want {
+				t.Errorf("want output: %q, got: %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestHasLogger(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"no logger", context.Background(), false},
+		{"nil logger", NewContext(context.Background(), nil), false},
+		{"logger", NewContext(context.Background(), New(os.Stdout, "", 0, false)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasLogger(tt.ctx); got != tt.want {
+				t.Errorf("HasLogger() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
-			Debugf(tt.args.format, tt.args.v...)
+func Test_callerLine(t *testing.T) {
+	state := Snapshot()
+	defer Restore(state)
 
-			if !re.Match(bytes.TrimSpace(buf.Bytes())) {
-				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetDebug(false)
+	SetFlags(log.Lshortfile)
+
+	tests := []struct {
+		name string
+		fn   func() int // logs and returns the line of the call
+	}{
+		{"Print", func() int { _, _, line, _ := runtime.Caller(0); Print("x"); return line }},
+		{"Printf", func() int { _, _, line, _ := runtime.Caller(0); Printf("%s", "x"); return line }},
+		{"Println", func() int { _, _, line, _ := runtime.Caller(0); Println("x"); return line }},
+		{"Panic", func() (line int) { defer func() { recover() }(); _, _, line, _ = runtime.Caller(0); Panic("x"); return }},
+		{"Logger.Print", func() int { _, _, line, _ := runtime.Caller(0); std.Print("x"); return line }},
+		{"Logger.Printf", func() int { _, _, line, _ := runtime.Caller(0); std.Printf("%s", "x"); return line }},
+		{"Logger.Println", func() int { _, _, line, _ := runtime.Caller(0); std.Println("x"); return line }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			line := tt.fn()
+			if want := "dlog_test.go:" + strconv.Itoa(line) + ": x\n"; buf.String() != want {
+				t.Errorf("want output: %q, got: %q", want, buf.String())
 			}
 		})
 	}
 }
 
-func Test_Printf(t *testing.T) {
+func TestLogger_WithOutput(t *testing.T) {
 	t.Parallel()
-	type args struct {
-		format string
-		v      []interface{}
+	var main, op bytes.Buffer
+	l := New(&main, "", 0, false)
+
+	l.Print("before")
+	l.WithOutput(&op, func() {
+		l.Print("during")
+	})
+	func() {
+		defer func() { recover() }()
+		l.WithOutput(&op, func() {
+			panic("boom")
+		})
+	}()
+	l.Print("after")
+
+	if want := "before\nafter\n"; main.String() != want {
+		t.Errorf("want main output: %q, got: %q", want, main.String())
+	}
+	if want := "during\n"; op.String() != want {
+		t.Errorf("want block output: %q, got: %q", want, op.String())
 	}
+	if l.Writer() != &main {
+		t.Error("output is not restored")
+	}
+}
+
+func TestLogger_nilReceiver(t *testing.T) {
+	// not parallel, nil receiver falls back to the standard logger.
+	defer Reset()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &http.Response{StatusCode: http.StatusOK, ProtoMajor: 1, ProtoMinor: 1, Header: http.Header{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
 	tests := []struct {
-		name         string
-		args         args
-		wantOutputRe string
+		name string
+		fn   func(l *Logger)
 	}{
-		{"debug is on",
-			args{format: "printf: %s%s", v: []interface{}{"message1 ", "message2"}},
-			`^.*printf: message1\s+message2`,
-		},
+		{"Print", func(l *Logger) { l.Print("x") }},
+		{"Printf", func(l *Logger) { l.Printf("%s", "x") }},
+		{"Println", func(l *Logger) { l.Println("x") }},
+		{"Output", func(l *Logger) { l.Output(1, "x") }},
+		{"Debug", func(l *Logger) { l.Debug("x") }},
+		{"Debugf", func(l *Logger) { l.Debugf("%s", "x") }},
+		{"Debugln", func(l *Logger) { l.Debugln("x") }},
+		{"Debugt", func(l *Logger) { l.Debugt("{a}", map[string]interface{}{"a": 1}) }},
+		{"DebugJSON", func(l *Logger) { l.DebugJSON("x", 1) }},
+		{"DebugDump", func(l *Logger) { l.DebugDump(1, 1) }},
+		{"DebugDiff", func(l *Logger) { l.DebugDiff("x", 1, 2) }},
+		{"DumpContext", func(l *Logger) { l.DumpContext(context.Background()) }},
+		{"DebugBytes", func(l *Logger) { l.DebugBytes("x", 1) }},
+		{"AuditChange", func(l *Logger) { l.AuditChange(1, 1) }},
+		{"LogPanic", func(l *Logger) { l.LogPanic("x") }},
+		{"Recover", func(l *Logger) { l.Recover() }},
+		{"DumpRequest", func(l *Logger) { l.DumpRequest(req, false) }},
+		{"DumpResponse", func(l *Logger) { l.DumpResponse(resp, false) }},
+		{"DebugHTTPHandler", func(l *Logger) { l.DebugHTTPHandler() }},
+		{"IsDebug", func(l *Logger) { l.IsDebug() }},
+		{"SetDebug", func(l *Logger) { l.SetDebug(false) }},
+		{"SetDebugOutput", func(l *Logger) { l.SetDebugOutput(nil) }},
+		{"Flags", func(l *Logger) { l.Flags() }},
+		{"SetFlags", func(l *Logger) { l.SetFlags(0) }},
+		{"Prefix", func(l *Logger) { l.Prefix() }},
+		{"SetPrefix", func(l *Logger) { l.SetPrefix("") }},
+		{"SetPrefixPosition", func(l *Logger) { l.SetPrefixPosition(PrefixBefore) }},
+		{"Writer", func(l *Logger) { l.Writer() }},
+		{"SetOutput", func(l *Logger) { l.SetOutput(&buf) }},
+		{"WithOutput", func(l *Logger) { l.WithOutput(&buf, func() {}) }},
+		{"SetIncludeGoroutineID", func(l *Logger) { l.SetIncludeGoroutineID(false) }},
+		{"SetIncludeSequence", func(l *Logger) { l.SetIncludeSequence(false) }},
+		{"SetPanicIncludesPrefix", func(l *Logger) { l.SetPanicIncludesPrefix(false) }},
+		{"SetPanicFormatter", func(l *Logger) { l.SetPanicFormatter(nil) }},
+		{"SerializeWrites", func(l *Logger) { l.SerializeWrites(true) }},
+		{"SetCallerSkipPackages", func(l *Logger) { l.SetCallerSkipPackages(nil) }},
+		{"SetRateLimit", func(l *Logger) { l.SetRateLimit(0) }},
+		{"SetEscapeNewlines", func(l *Logger) { l.SetEscapeNewlines(false) }},
+		{"AddTransform", func(l *Logger) { l.AddTransform(strings.TrimSpace) }},
+		{"AddFilter", func(l *Logger) { l.AddFilter(regexp.MustCompile("^$")) }},
+		{"DeferredMode", func(l *Logger) { l.DeferredMode(false) }},
+		{"Flush", func(l *Logger) { l.Flush() }},
+		{"WriteRaw", func(l *Logger) { l.WriteRaw([]byte("x\n")) }},
+		{"Write", func(l *Logger) { l.Write([]byte("x\n")) }},
+		{"SetWriteTrimNewline", func(l *Logger) { l.SetWriteTrimNewline(true) }},
+		{"WarnOnce", func(l *Logger) { l.WarnOnce("x", "x") }},
+		{"Banner", func(l *Logger) { l.Banner(BuildInfo{}) }},
+		{"Group", func(l *Logger) { l.Group("x").Close() }},
+		{"Enter", func(l *Logger) { l.Enter("x")() }},
+		{"LogIfSlow", func(l *Logger) { l.LogIfSlow(0, "x")() }},
+		{"Around", func(l *Logger) { l.Around("x", func() error { return nil }) }},
+		{"Measure", func(l *Logger) { l.Measure("x", func() error { return nil }) }},
+		{"LogRetry", func(l *Logger) { l.LogRetry(1, 2, nil, 0) }},
+		{"NewLatencyTracker", func(l *Logger) { l.NewLatencyTracker("x").Report() }},
+		{"Heartbeat", func(l *Logger) { l.Heartbeat(ctx, time.Hour, "x") }},
+		{"Context", func(l *Logger) { l.Context(context.Background()) }},
+		{"Dropped", func(l *Logger) { l.Dropped() }},
+		{"Pipe", func(l *Logger) {
+			r, detach := l.Pipe()
+			detach()
+			ioutil.ReadAll(r)
+		}},
+		{"Apply", func(l *Logger) { l.Apply(WithPrefix("")) }},
+		{"Snapshot", func(l *Logger) { l.Snapshot() }},
+		{"Restore", func(l *Logger) { l.Restore(std.Snapshot()) }},
+		{"Equal", func(l *Logger) { l.Equal(std) }},
+		{"Panic", func(l *Logger) { expectPanic("x", func() { l.Panic("x") }) }},
+		{"Panicf", func(l *Logger) { expectPanic("x", func() { l.Panicf("%s", "x") }) }},
+		{"Panicln", func(l *Logger) { expectPanic("x\n", func() { l.Panicln("x") }) }},
+		{"Fatal", func(l *Logger) { expectExit(func() { l.Fatal("x") }) }},
+		{"Fatalf", func(l *Logger) { expectExit(func() { l.Fatalf("%s", "x") }) }},
+		{"Fatalln", func(l *Logger) { expectExit(func() { l.Fatalln("x") }) }},
+		{"Close", func(l *Logger) { l.Close() }},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			re, err := regexp.Compile(tt.wantOutputRe)
-			if err != nil {
-				t.Fatal(err)
-			}
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s on nil *Logger panicked: %v", tt.name, r)
+				}
+			}()
+			var l *Logger
+			tt.fn(l)
+		})
+	}
+}
+
+// expectPanic calls fn and recovers the panic with the value want.  Any other
+// panic, such as a nil pointer dereference, is propagated.
+func expectPanic(want string, fn func()) {
+	defer func() {
+		if r := recover(); r != want {
+			panic(fmt.Sprintf("want panic %q, got: %v", want, r))
+		}
+	}()
+	fn()
+}
 
+// expectExit calls fn with OsExit replaced, and panics if fn did not exit
+// with code 1.
+func expectExit(fn func()) {
+	defer func() { OsExit = os.Exit }()
+	code := -1
+	OsExit = func(c int) { code = c }
+	fn()
+	if code != 1 {
+		panic(fmt.Sprintf("want exit code 1, got: %d", code))
+	}
+}
+
+func TestLogger_Write(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		keepNL bool
+		input  []string
+		want   string
+	}{
+		{"trim", false, []string{"one\n", "two", "three\n\n"}, "one\ntwo\nthree\n"},
+		{"no trim", true, []string{"one\n", "two", "three\n\n"}, "one\ntwo\nthree\n\n"},
+		{"empty line, trim", false, []string{"\n"}, "\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
 			var buf bytes.Buffer
-			SetOutput(&buf)
-			defer SetOutput(os.Stderr)
+			l := New(&buf, "", 0, false)
+			l.SetWriteTrimNewline(!tt.keepNL)
+			for _, s := range tt.input {
+				n, err := l.Write([]byte(s))
+				if err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			if buf.String() != tt.want {
+				t.Errorf("want: %q, got: %q", tt.want, buf.String())
+			}
+		})
+	}
+}
 
-			Printf(tt.args.format, tt.args.v...)
+func TestLogger_Write_asOutput(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "dlog: ", 0, false)
+	lg := log.New(l, "", 0)
+	lg.Print("forwarded")
+	if want := "dlog: forwarded\n"; buf.String() != want {
+		t.Errorf("want: %q, got: %q", want, buf.String())
+	}
+}
 
-			if !re.Match(bytes.TrimSpace(buf.Bytes())) {
-				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+func TestOsExit(t *testing.T) {
+	// not parallel, changes OsExit.
+	defer func() { OsExit = os.Exit }()
+	var code int
+	OsExit = func(c int) { code = c }
+
+	tests := []struct {
+		name  string
+		fatal func(l *Logger)
+		want  string
+	}{
+		{"Fatal", func(l *Logger) { l.Fatal("fatal ", 1) }, "fatal 1\n"},
+		{"Fatalf", func(l *Logger) { l.Fatalf("fatal %d", 2) }, "fatal 2\n"},
+		{"Fatalln", func(l *Logger) { l.Fatalln("fatal", 3) }, "fatal 3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code = 0
+			var buf bytes.Buffer
+			tt.fatal(New(&buf, "", 0, false))
+			if code != 1 {
+				t.Errorf("want exit code 1, got: %d", code)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("want output: %q, got: %q", tt.want, buf.String())
 			}
 		})
 	}
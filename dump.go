@@ -0,0 +1,401 @@
+package dlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DebugJSON logs the value v marshalled to JSON as "label: <json>", if the
+// debug output is enabled.  If v can't be marshalled, the error is logged
+// instead.  When the debug output is disabled, v is not marshalled.
+func (l *Logger) DebugJSON(label string, v interface{}) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, jsonString(label, v))
+	}
+}
+
+// DebugJSON logs the value v marshalled to JSON to the standard logger, if
+// the debug output is enabled.
+func DebugJSON(label string, v interface{}) {
+	if std.IsDebug() {
+		std.debugOutput(2, jsonString(label, v))
+	}
+}
+
+func jsonString(label string, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%s: error marshalling to JSON: %s", label, err)
+	}
+	return label + ": " + string(data)
+}
+
+// DumpContext logs the deadline and the error of ctx, if the debug output is
+// enabled:
+//
+//	context: deadline=2006-01-02T15:04:05Z remaining=1.5s err=<nil>
+//
+// If ctx has no deadline, it is logged as "deadline=none".  The context
+// values can't be enumerated, so they are not logged.
+func (l *Logger) DumpContext(ctx context.Context) {
+	l = l.orStd()
+	if l.IsDebug() {
+		l.debugOutput(2, contextString(ctx))
+	}
+}
+
+// DumpContext logs the deadline and the error of ctx to the standard logger,
+// if the debug output is enabled.
+func DumpContext(ctx context.Context) {
+	if std.IsDebug() {
+		std.debugOutput(2, contextString(ctx))
+	}
+}
+
+func contextString(ctx context.Context) string {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fmt.Sprintf("context: deadline=none err=%v", ctx.Err())
+	}
+	return fmt.Sprintf("context: deadline=%s remaining=%s err=%v",
+		deadline.Format(time.RFC3339Nano), time.Until(deadline).Round(time.Millisecond), ctx.Err())
+}
+
+// DebugBytes logs the byte count n as "label: 1.5 MiB (1572864 bytes)", if
+// the debug output is enabled.
+func (l *Logger) DebugBytes(label string, n int64) {
+	l = l.orStd()
+	if l.IsDebug() {
+		l.debugOutput(2, bytesString(label, n))
+	}
+}
+
+// DebugBytes logs the byte count n to the standard logger, if the debug
+// output is enabled.
+func DebugBytes(label string, n int64) {
+	if std.IsDebug() {
+		std.debugOutput(2, bytesString(label, n))
+	}
+}
+
+func bytesString(label string, n int64) string {
+	return fmt.Sprintf("%s: %s (%d bytes)", label, HumanBytes(n), n)
+}
+
+// HumanBytes returns the byte count n in binary units, i.e. "512 B",
+// "1.5 MiB".
+func HumanBytes(n int64) string {
+	const unit = 1024
+	abs := uint64(n)
+	if n < 0 {
+		abs = uint64(-n) // correct for math.MinInt64 too
+	}
+	if abs < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := abs / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+	// the unit is chosen on the rounded value, so that 1048575 is 1.0 MiB
+	// and not 1024.0 KiB.
+	if math.Round(float64(abs)/float64(div)*10) >= unit*10 && exp < 5 {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// DebugDump logs the value v, if the debug output is enabled.  Structs, maps,
+// slices and arrays are rendered up to maxDepth levels deep, deeper values
+// are replaced with "...".  When the debug output is disabled, v is not
+// inspected.
+func (l *Logger) DebugDump(v interface{}, maxDepth int) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, dumpString(v, maxDepth))
+	}
+}
+
+// DebugDump logs the value v to the standard logger up to maxDepth levels
+// deep, if the debug output is enabled.
+func DebugDump(v interface{}, maxDepth int) {
+	if std.IsDebug() {
+		std.debugOutput(2, dumpString(v, maxDepth))
+	}
+}
+
+func dumpString(v interface{}, maxDepth int) string {
+	return dumpValue(reflect.ValueOf(v), maxDepth)
+}
+
+func dumpValue(v reflect.Value, maxDepth int) string {
+	d := dumper{maxDepth: maxDepth, seen: make(map[uintptr]bool)}
+	d.dump(v, 0)
+	return d.sb.String()
+}
+
+// stringValue returns the string of v, if it implements error or
+// fmt.Stringer, such as time.Time, which is more useful than its fields.
+func stringValue(v reflect.Value) (string, bool) {
+	if !v.IsValid() || !v.CanInterface() || v.Kind() == reflect.Interface {
+		return "", false
+	}
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return "", false
+	}
+	switch x := v.Interface().(type) {
+	case error, fmt.Stringer:
+		return fmt.Sprint(x), true // fmt recovers if the method panics.
+	}
+	return "", false
+}
+
+// dumper renders values in the format similar to %+v, with the depth limit.
+type dumper struct {
+	sb       strings.Builder
+	maxDepth int
+	seen     map[uintptr]bool // pointers being rendered, to detect cycles
+}
+
+func (d *dumper) dump(v reflect.Value, depth int) {
+	if s, ok := stringValue(v); ok {
+		d.sb.WriteString(s)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		d.sb.WriteString("<nil>")
+	case reflect.Interface:
+		if v.IsNil() {
+			d.sb.WriteString("<nil>")
+			return
+		}
+		d.dump(v.Elem(), depth)
+	case reflect.Ptr:
+		if v.IsNil() {
+			d.sb.WriteString("<nil>")
+			return
+		}
+		if d.seen[v.Pointer()] {
+			d.sb.WriteString("<cycle>")
+			return
+		}
+		d.seen[v.Pointer()] = true
+		defer delete(d.seen, v.Pointer())
+		d.sb.WriteByte('&')
+		d.dump(v.Elem(), depth)
+	case reflect.Struct:
+		if depth >= d.maxDepth {
+			d.sb.WriteString("{...}")
+			return
+		}
+		d.sb.WriteByte('{')
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				d.sb.WriteByte(' ')
+			}
+			d.sb.WriteString(v.Type().Field(i).Name)
+			d.sb.WriteByte(':')
+			d.dump(v.Field(i), depth+1)
+		}
+		d.sb.WriteByte('}')
+	case reflect.Map:
+		if v.IsNil() {
+			d.sb.WriteString("map[]")
+			return
+		}
+		if depth >= d.maxDepth {
+			d.sb.WriteString("map[...]")
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		d.sb.WriteString("map[")
+		for i, k := range keys {
+			if i > 0 {
+				d.sb.WriteByte(' ')
+			}
+			fmt.Fprint(&d.sb, k)
+			d.sb.WriteByte(':')
+			d.dump(v.MapIndex(k), depth+1)
+		}
+		d.sb.WriteByte(']')
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			d.sb.WriteString("[]")
+			return
+		}
+		if depth >= d.maxDepth {
+			d.sb.WriteString("[...]")
+			return
+		}
+		d.sb.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				d.sb.WriteByte(' ')
+			}
+			d.dump(v.Index(i), depth+1)
+		}
+		d.sb.WriteByte(']')
+	default:
+		fmt.Fprint(&d.sb, v)
+	}
+}
+
+// DebugDiff logs the differences between the old and new values, if the
+// debug output is enabled, as "label: Field: old->new, ...".  Structs, maps,
+// slices and arrays are compared element by element, the differences are
+// reported by the path of the changed element, i.e. "Config.Hosts[1]".  When
+// the debug output is disabled, the values are not compared.
+func (l *Logger) DebugDiff(label string, old, new interface{}) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, diffString(label, old, new))
+	}
+}
+
+// DebugDiff logs the differences between the old and new values to the
+// standard logger, if the debug output is enabled.
+func DebugDiff(label string, old, new interface{}) {
+	if std.IsDebug() {
+		std.debugOutput(2, diffString(label, old, new))
+	}
+}
+
+// maxDiffDepth limits the depth of the comparison in diff, which protects
+// against cyclic values.
+const maxDiffDepth = 32
+
+func diffString(label string, old, new interface{}) string {
+	var changes []string
+	diff(&changes, "", reflect.ValueOf(old), reflect.ValueOf(new), 0)
+	if len(changes) == 0 {
+		return label + ": no changes"
+	}
+	return label + ": " + strings.Join(changes, ", ")
+}
+
+// diff appends the differences between a and b to changes, path is the path
+// of a and b within the compared values.
+func diff(changes *[]string, path string, a, b reflect.Value, depth int) {
+	change := func() {
+		c := fmt.Sprintf("%s->%s", dumpValue(a, 1), dumpValue(b, 1))
+		if path != "" {
+			c = path + ": " + c
+		}
+		*changes = append(*changes, c)
+	}
+	if !a.IsValid() && !b.IsValid() {
+		return
+	}
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		change()
+		return
+	}
+	if depth > maxDiffDepth {
+		return
+	}
+	if eq, ok := leafEqual(a, b); ok {
+		if !eq {
+			change()
+		}
+		return
+	}
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				change()
+			}
+			return
+		}
+		diff(changes, path, a.Elem(), b.Elem(), depth+1)
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			diff(changes, joinPath(path, a.Type().Field(i).Name), a.Field(i), b.Field(i), depth+1)
+		}
+	case reflect.Map:
+		keys := a.MapKeys()
+		for _, k := range b.MapKeys() {
+			if !a.MapIndex(k).IsValid() {
+				keys = append(keys, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, k := range keys {
+			diff(changes, fmt.Sprintf("%s[%v]", path, k), a.MapIndex(k), b.MapIndex(k), depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		n := a.Len()
+		if b.Len() > n {
+			n = b.Len()
+		}
+		for i := 0; i < n; i++ {
+			var ai, bi reflect.Value
+			if i < a.Len() {
+				ai = a.Index(i)
+			}
+			if i < b.Len() {
+				bi = b.Index(i)
+			}
+			diff(changes, fmt.Sprintf("%s[%d]", path, i), ai, bi, depth+1)
+		}
+	default:
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			change()
+		}
+	}
+}
+
+// leafEqual compares a and b of the same type as whole values, if they have
+// an Equal method, such as time.Time, or implement error or fmt.Stringer.  It
+// returns false in ok, if a and b should be compared field by field.
+func leafEqual(a, b reflect.Value) (eq, ok bool) {
+	if !a.CanInterface() || a.Kind() == reflect.Interface {
+		return false, false
+	}
+	if a.Kind() == reflect.Ptr && (a.IsNil() || b.IsNil()) {
+		return false, false
+	}
+	if m := a.MethodByName("Equal"); m.IsValid() {
+		mt := m.Type()
+		if mt.NumIn() == 1 && mt.In(0) == a.Type() && mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Bool {
+			return m.Call([]reflect.Value{b})[0].Bool(), true
+		}
+	}
+	as, ok := stringValue(a)
+	if !ok {
+		return false, false
+	}
+	bs, _ := stringValue(b)
+	return as == bs, true
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,134 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestLogger_DebugJSON(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		label string
+		v     interface{}
+	}
+	tests := []struct {
+		name         string
+		debug        bool
+		args         args
+		wantOutputRe string
+	}{
+		{"debug is on",
+			true,
+			args{"value", map[string]int{"a": 1}},
+			`^dump_debug_test\.go:\d+: value: \{"a":1\}$`,
+		},
+		{"marshal error",
+			true,
+			args{"value", make(chan int)},
+			`^dump_debug_test\.go:\d+: value: error marshalling to JSON: .*chan int$`,
+		},
+		{"debug is off",
+			false,
+			args{"value", map[string]int{"a": 1}},
+			`^$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, tt.debug)
+			l.DebugJSON(tt.args.label, tt.args.v)
+
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_DebugDump(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	l.DebugDump([]int{1}, 1)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output with debug off: %q", buf.String())
+	}
+	l.SetDebug(true)
+	l.DebugDump([]int{1}, 1)
+	if want := regexp.MustCompile(`^dump_debug_test\.go:\d+: \[1\]\n$`); !want.MatchString(buf.String()) {
+		t.Errorf("output mismatch: wantRE: %q, got: %q", want, buf.String())
+	}
+}
+
+func TestLogger_DebugDiff(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	l.DebugDiff("state", 1, 2)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output with debug off: %q", buf.String())
+	}
+	l.SetDebug(true)
+	l.DebugDiff("state", 1, 2)
+	if want := regexp.MustCompile(`^dump_debug_test\.go:\d+: state: 1->2\n$`); !want.MatchString(buf.String()) {
+		t.Errorf("output mismatch: wantRE: %q, got: %q", want, buf.String())
+	}
+}
+
+func TestLogger_DumpContext(t *testing.T) {
+	t.Parallel()
+	withDeadline, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	cancelled, cancel2 := context.WithCancel(context.Background())
+	cancel2()
+	tests := []struct {
+		name         string
+		debug        bool
+		ctx          context.Context
+		wantOutputRe string
+	}{
+		{"no deadline", true, context.Background(), `^dump_debug_test\.go:\d+: context: deadline=none err=<nil>$`},
+		{"deadline", true, withDeadline, `^dump_debug_test\.go:\d+: context: deadline=\S+ remaining=\S+ err=<nil>$`},
+		{"cancelled", true, cancelled, `^dump_debug_test\.go:\d+: context: deadline=none err=context canceled$`},
+		{"debug is off", false, context.Background(), `^$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, tt.debug)
+			l.DumpContext(tt.ctx)
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_DebugBytes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		debug        bool
+		wantOutputRe string
+	}{
+		{"debug is on", true, `^dump_debug_test\.go:\d+: read: 1\.5 MiB \(1572864 bytes\)$`},
+		{"debug is off", false, `^$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, tt.debug)
+			l.DebugBytes("read", 1572864)
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package dlog
+
+import (
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_dumpString(t *testing.T) {
+	type inner struct {
+		S []int
+	}
+	type outer struct {
+		Name  string
+		In    inner
+		Ptr   *inner
+		M     map[string]int
+		Iface interface{}
+	}
+	type node struct {
+		Next *node
+	}
+	cyclic := &node{}
+	cyclic.Next = cyclic
+
+	v := outer{Name: "x", In: inner{S: []int{1, 2}}, Ptr: &inner{}, M: map[string]int{"b": 2, "a": 1}}
+	tests := []struct {
+		name     string
+		v        interface{}
+		maxDepth int
+		want     string
+	}{
+		{"nil", nil, 1, "<nil>"},
+		{"scalar", 42, 0, "42"},
+		{"depth 0", v, 0, "{...}"},
+		{"depth 1", v, 1, "{Name:x In:{...} Ptr:&{...} M:map[...] Iface:<nil>}"},
+		{"depth 2", v, 2, "{Name:x In:{S:[...]} Ptr:&{S:[]} M:map[a:1 b:2] Iface:<nil>}"},
+		{"depth 3", v, 3, "{Name:x In:{S:[1 2]} Ptr:&{S:[]} M:map[a:1 b:2] Iface:<nil>}"},
+		{"cycle", cyclic, 10, "&{Next:<cycle>}"},
+		{"stringer", time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), 1, "2023-01-02 15:04:05 +0000 UTC"},
+		{"error", errors.New("x"), 1, "x"},
+		{"stringer field", struct{ T time.Duration }{time.Second}, 1, "{T:1s}"},
+		{"nil error pointer", (*os.PathError)(nil), 1, "<nil>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dumpString(tt.v, tt.maxDepth); got != tt.want {
+				t.Errorf("dumpString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_diffString(t *testing.T) {
+	type server struct {
+		Host  string
+		Port  int
+		Hosts []string
+		Opts  map[string]int
+		Next  *server
+	}
+	tests := []struct {
+		name     string
+		old, new interface{}
+		want     string
+	}{
+		{"equal", server{Host: "a"}, server{Host: "a"}, "x: no changes"},
+		{"scalar", 1, 2, "x: 1->2"},
+		{"different types", 1, "1", "x: 1->1"},
+		{"nil and value", nil, 1, "x: <nil>->1"},
+		{"struct fields",
+			server{Host: "a", Port: 1},
+			server{Host: "b", Port: 1},
+			"x: Host: a->b",
+		},
+		{"nested",
+			server{Hosts: []string{"a", "b"}, Opts: map[string]int{"k": 1, "old": 1}, Next: &server{Port: 1}},
+			server{Hosts: []string{"a", "c", "d"}, Opts: map[string]int{"k": 2, "new": 1}, Next: &server{Port: 2}},
+			"x: Hosts[1]: b->c, Hosts[2]: <nil>->d, Opts[k]: 1->2, Opts[new]: <nil>->1, Opts[old]: 1-><nil>, Next.Port: 1->2",
+		},
+		{"nil pointer", server{}, server{Next: &server{}}, "x: Next: <nil>->&{Host: Port:0 Hosts:[] Opts:map[] Next:<nil>}"},
+		{"time",
+			struct{ Updated time.Time }{time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)},
+			struct{ Updated time.Time }{time.Date(2023, 1, 2, 16, 0, 0, 0, time.UTC)},
+			"x: Updated: 2023-01-02 15:00:00 +0000 UTC->2023-01-02 16:00:00 +0000 UTC",
+		},
+		{"equal time in other location",
+			time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC),
+			time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC).In(time.FixedZone("X", 3600)),
+			"x: no changes",
+		},
+		{"error", errors.New("a"), errors.New("b"), "x: a->b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffString("x", tt.old, tt.new); got != tt.want {
+				t.Errorf("diffString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1572864, "1.5 MiB"},
+		{5 << 30, "5.0 GiB"},
+		{-2048, "-2.0 KiB"},
+		{1048575, "1.0 MiB"},
+		{1048524, "1023.9 KiB"},
+		{1<<30 - 1, "1.0 GiB"},
+		{-(1<<20 - 1), "-1.0 MiB"},
+		{math.MaxInt64, "8.0 EiB"},
+		{math.MinInt64, "-8.0 EiB"},
+	}
+	for _, tt := range tests {
+		if got := HumanBytes(tt.n); got != tt.want {
+			t.Errorf("HumanBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/rusq/dlog
 
 go 1.14
+
+require (
+	github.com/go-logr/logr v1.2.4
+	google.golang.org/protobuf v1.28.1
+)
@@ -0,0 +1,75 @@
+package dlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// GroupLogger accumulates the related log entries and writes them together
+// on Close, so that they are not interleaved with other output.  Each entry
+// is written as a separate line, prefixed with the group name.  The entries
+// are written with the time of Close.
+type GroupLogger struct {
+	l    *Logger
+	name string
+
+	mu      sync.Mutex
+	entries []string
+}
+
+// Group returns a new GroupLogger with the given name, that writes to l.
+func (l *Logger) Group(name string) *GroupLogger {
+	l = l.orStd()
+	return &GroupLogger{l: l, name: name}
+}
+
+// Group returns a new GroupLogger with the given name, that writes to the
+// standard logger.
+func Group(name string) *GroupLogger {
+	return std.Group(name)
+}
+
+// Print adds an entry to the group.  Arguments are handled in the manner of
+// fmt.Print.
+func (g *GroupLogger) Print(v ...interface{}) {
+	g.add(fmt.Sprint(v...))
+}
+
+// Printf adds an entry to the group.  Arguments are handled in the manner of
+// fmt.Printf.
+func (g *GroupLogger) Printf(format string, v ...interface{}) {
+	g.add(fmt.Sprintf(format, v...))
+}
+
+// Println adds an entry to the group.  Arguments are handled in the manner of
+// fmt.Println.
+func (g *GroupLogger) Println(v ...interface{}) {
+	g.add(fmt.Sprintln(v...))
+}
+
+func (g *GroupLogger) add(s string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.entries = append(g.entries, strings.TrimSuffix(s, "\n"))
+}
+
+// Close writes the accumulated entries to the logger, one per line, as
+// "name: entry".  The entries are written under a single lock of the logger,
+// so the lines written by other goroutines can't get between them, unless
+// the serialization is disabled with SerializeWrites(false).  The entries
+// are removed, so the GroupLogger can be reused.
+func (g *GroupLogger) Close() error {
+	g.mu.Lock()
+	entries := g.entries
+	g.entries = nil
+	g.mu.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = g.name + ": " + e
+	}
+	return g.l.outputLines(kindNormal, 2, lines)
+}
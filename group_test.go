@@ -0,0 +1,89 @@
+package dlog
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogger_Group(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	g := l.Group("req")
+	g.Print("step ", 1)
+	l.Print("unrelated")
+	g.Printf("step %d", 2)
+	g.Println("step", 3)
+	if want := "unrelated\n"; buf.String() != want {
+		t.Errorf("entries are written before Close: %q", buf.String())
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "unrelated\nreq: step 1\nreq: step 2\nreq: step 3\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "unrelated\nreq: step 1\nreq: step 2\nreq: step 3\n"; buf.String() != want {
+		t.Errorf("second Close must not write again, got: %q", buf.String())
+	}
+}
+
+// yieldWriter yields the processor on every write, to let the other
+// goroutines run while the logger writes.
+type yieldWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *yieldWriter) Write(p []byte) (int, error) {
+	runtime.Gosched()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestLogger_Group_concurrent(t *testing.T) {
+	t.Parallel()
+	var w yieldWriter
+	l := New(&w, "", 0, false)
+	const n = 1000
+	g := l.Group("req")
+	for i := 0; i < n; i++ {
+		g.Print("step ", i)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				l.Print("other")
+			}
+		}
+	}()
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+	wg.Wait()
+
+	var want strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&want, "req: step %d\n", i)
+	}
+	if !strings.Contains(w.buf.String(), want.String()) {
+		t.Error("group entries are interleaved with other output")
+	}
+}
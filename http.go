@@ -0,0 +1,152 @@
+package dlog
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DumpRequest logs the outgoing client request, optionally with the body, if
+// the debug output is enabled.  The request body remains readable after the
+// dump.  When the debug output is disabled, the request is not touched.
+func (l *Logger) DumpRequest(req *http.Request, body bool) {
+	l = l.orStd()
+	if !l.IsDebug() {
+		return
+	}
+	data, err := httputil.DumpRequestOut(req, body)
+	if err != nil {
+		l.debugOutput(2, "error dumping request: "+err.Error())
+		return
+	}
+	l.debugOutput(2, "request:\n"+string(data))
+}
+
+// DumpResponse logs the response, optionally with the body, if the debug
+// output is enabled.  The response body remains readable after the dump.
+// When the debug output is disabled, the response body is not read.
+func (l *Logger) DumpResponse(resp *http.Response, body bool) {
+	l = l.orStd()
+	if !l.IsDebug() {
+		return
+	}
+	data, err := httputil.DumpResponse(resp, body)
+	if err != nil {
+		l.debugOutput(2, "error dumping response: "+err.Error())
+		return
+	}
+	l.debugOutput(2, "response:\n"+string(data))
+}
+
+// DumpRequest logs the outgoing client request to the standard logger, if
+// the debug output is enabled.
+func DumpRequest(req *http.Request, body bool) {
+	if !std.IsDebug() {
+		return
+	}
+	data, err := httputil.DumpRequestOut(req, body)
+	if err != nil {
+		std.debugOutput(2, "error dumping request: "+err.Error())
+		return
+	}
+	std.debugOutput(2, "request:\n"+string(data))
+}
+
+// DumpResponse logs the response to the standard logger, if the debug output
+// is enabled.
+func DumpResponse(resp *http.Response, body bool) {
+	if !std.IsDebug() {
+		return
+	}
+	data, err := httputil.DumpResponse(resp, body)
+	if err != nil {
+		std.debugOutput(2, "error dumping response: "+err.Error())
+		return
+	}
+	std.debugOutput(2, "response:\n"+string(data))
+}
+
+var debugPage = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>dlog</title></head>
+<body>
+<h1>Logger</h1>
+<table>
+<tr><td>Debug</td><td>{{.Debug}}</td></tr>
+<tr><td>Prefix</td><td>{{printf "%q" .Prefix}}</td></tr>
+<tr><td>Flags</td><td>{{.Flags}}</td></tr>
+</table>
+<form method="post">
+<input type="hidden" name="debug" value="{{not .Debug}}">
+<input type="submit" value="{{if .Debug}}Disable{{else}}Enable{{end}} debug">
+</form>
+</body>
+</html>
+`))
+
+// DebugHTTPHandler returns the handler of the logger admin page, that can be
+// mounted, for example, at "/debug/log".  GET shows the logger settings.
+// POST with the form value "debug" set to "true" or "false" enables or
+// disables the debug output, and redirects back to the page.
+//
+// The handler does not authenticate the requests, it must be mounted behind
+// the authentication, as anyone who can reach it can enable the debug
+// output.  To protect against the cross-site request forgery, POST is only
+// accepted if its Origin, or if it is missing, its Referer, matches the
+// request host.
+func (l *Logger) DebugHTTPHandler() http.Handler {
+	l = l.orStd()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost:
+			if !sameOrigin(r) {
+				http.Error(w, "cross-origin request", http.StatusForbidden)
+				return
+			}
+			debug, err := strconv.ParseBool(r.FormValue("debug"))
+			if err != nil {
+				http.Error(w, "invalid debug value: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.SetDebug(debug)
+			http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+			return
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugPage.Execute(w, struct {
+			Debug  bool
+			Prefix string
+			Flags  int
+		}{l.IsDebug(), l.Prefix(), l.Flags()})
+	})
+}
+
+// sameOrigin returns true if the Origin header of r, or the Referer header if
+// there is no Origin, points to the host of r.
+func sameOrigin(r *http.Request) bool {
+	src := r.Header.Get("Origin")
+	if src == "" {
+		src = r.Referer()
+	}
+	if src == "" {
+		return false
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// DebugHTTPHandler returns the handler of the standard logger admin page.
+func DebugHTTPHandler() http.Handler {
+	return std.DebugHTTPHandler()
+}
@@ -0,0 +1,162 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func testResponse() *http.Response {
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader("response body")),
+	}
+}
+
+func TestLogger_DumpResponse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		debug    bool
+		body     bool
+		want     []string
+		dontWant []string
+	}{
+		{"with body", true, true, []string{"response:", "200 OK", "Content-Type: text/plain", "response body"}, nil},
+		{"without body", true, false, []string{"response:", "200 OK"}, []string{"response body"}},
+		{"debug is off", false, true, nil, []string{"response"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, tt.debug)
+			resp := testResponse()
+			l.DumpResponse(resp, tt.body)
+
+			for _, s := range tt.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("output does not contain %q: %q", s, buf.String())
+				}
+			}
+			for _, s := range tt.dontWant {
+				if strings.Contains(buf.String(), s) {
+					t.Errorf("output contains %q: %q", s, buf.String())
+				}
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "response body" {
+				t.Errorf("response body was consumed, got: %q", body)
+			}
+		})
+	}
+}
+
+func TestLogger_DumpRequest(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, true)
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/path", strings.NewReader("request body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.DumpRequest(req, true)
+	for _, s := range []string{"request:", "POST /path HTTP/1.1", "Host: example.com", "request body"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("output does not contain %q: %q", s, buf.String())
+		}
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "request body" {
+		t.Errorf("request body was consumed, got: %q", body)
+	}
+}
+
+func TestLogger_DebugHTTPHandler(t *testing.T) {
+	t.Parallel()
+	l := New(&bytes.Buffer{}, "pfx: ", 0, false)
+	h := l.DebugHTTPHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/log", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET: want status %d, got: %d", http.StatusOK, rec.Code)
+	}
+	for _, s := range []string{"<td>false</td>", `&#34;pfx: &#34;`, `value="true"`} {
+		if !strings.Contains(rec.Body.String(), s) {
+			t.Errorf("GET: page does not contain %q: %s", s, rec.Body.String())
+		}
+	}
+
+	sameOrigin := http.Header{"Origin": {"http://example.com"}} // the host of httptest requests
+	post := func(v string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/debug/log", strings.NewReader(url.Values{"debug": {v}}.Encode()))
+		for k, vv := range header {
+			req.Header[k] = vv
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := post("true", sameOrigin); rec.Code != http.StatusSeeOther {
+		t.Errorf("POST: want status %d, got: %d", http.StatusSeeOther, rec.Code)
+	}
+	if !l.IsDebug() {
+		t.Error("POST did not enable debug")
+	}
+	if rec := post("maybe", sameOrigin); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST invalid: want status %d, got: %d", http.StatusBadRequest, rec.Code)
+	}
+	if !l.IsDebug() {
+		t.Error("invalid POST changed debug")
+	}
+	if rec := post("false", http.Header{"Referer": {"http://example.com/debug/log"}}); rec.Code != http.StatusSeeOther {
+		t.Errorf("POST with Referer: want status %d, got: %d", http.StatusSeeOther, rec.Code)
+	}
+	if l.IsDebug() {
+		t.Error("POST with Referer did not disable debug")
+	}
+
+	forbidden := []struct {
+		name   string
+		header http.Header
+	}{
+		{"no origin", nil},
+		{"other origin", http.Header{"Origin": {"http://evil.example"}}},
+		{"null origin", http.Header{"Origin": {"null"}}},
+		{"other referer", http.Header{"Referer": {"http://evil.example/page"}}},
+		{"origin wins over referer", http.Header{"Origin": {"http://evil.example"}, "Referer": {"http://example.com/"}}},
+	}
+	for _, tt := range forbidden {
+		if rec := post("true", tt.header); rec.Code != http.StatusForbidden {
+			t.Errorf("POST %s: want status %d, got: %d", tt.name, http.StatusForbidden, rec.Code)
+		}
+		if l.IsDebug() {
+			t.Errorf("POST %s changed debug", tt.name)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/debug/log", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: want status %d, got: %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
@@ -0,0 +1,16 @@
+// Package wrapper is a helper logging layer for the caller tests of dlog.
+package wrapper
+
+// Printer is the logger.
+type Printer interface {
+	Print(v ...interface{})
+}
+
+// Print prints v through one more layer of calls.
+func Print(p Printer, v ...interface{}) {
+	print(p, v...)
+}
+
+func print(p Printer, v ...interface{}) {
+	p.Print(v...)
+}
@@ -0,0 +1,102 @@
+//go:build dloglogr
+// +build dloglogr
+
+package dlog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// Logr returns the logr.Logger backed by l, for the libraries that expect
+// it.  The V(0) lines are logged to the output, the lines with the higher
+// verbosity are logged to the debug output, and only if it is enabled.  The
+// names are joined with "/" and prepended to the message, the key-value
+// pairs are appended to it as " key=value".
+//
+// It is only available when building with the "dloglogr" build tag, so
+// that the logr dependency is not imposed on everyone.
+func (l *Logger) Logr() logr.Logger {
+	l = l.orStd()
+	return logr.New(&logrSink{l: l})
+}
+
+// Logr returns the logr.Logger backed by the standard logger.
+func Logr() logr.Logger {
+	return std.Logr()
+}
+
+// logrSink is the logr.LogSink that writes to Logger.
+type logrSink struct {
+	l      *Logger
+	name   string
+	values []interface{}
+	depth  int // additional frames to skip when looking for the caller
+}
+
+func (s *logrSink) Init(info logr.RuntimeInfo) {
+	s.depth += info.CallDepth
+}
+
+func (s *logrSink) Enabled(level int) bool {
+	return level <= 0 || s.l.IsDebug()
+}
+
+func (s *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if level > 0 {
+		if s.l.IsDebug() {
+			s.l.debugOutput(s.depth+2, s.format(msg, keysAndValues))
+		}
+		return
+	}
+	s.l.Output(s.depth+2, s.format(msg, keysAndValues))
+}
+
+func (s *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.l.Output(s.depth+2, s.format(msg, append(keysAndValues[:len(keysAndValues):len(keysAndValues)], "error", err)))
+}
+
+func (s *logrSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	c := *s
+	c.values = append(s.values[:len(s.values):len(s.values)], keysAndValues...)
+	return &c
+}
+
+func (s *logrSink) WithName(name string) logr.LogSink {
+	c := *s
+	if c.name != "" {
+		c.name += "/"
+	}
+	c.name += name
+	return &c
+}
+
+func (s *logrSink) WithCallDepth(depth int) logr.LogSink {
+	c := *s
+	c.depth += depth
+	return &c
+}
+
+// format returns the message with the name and the key-value pairs.
+func (s *logrSink) format(msg string, keysAndValues []interface{}) string {
+	var sb strings.Builder
+	if s.name != "" {
+		sb.WriteString(s.name + ": ")
+	}
+	sb.WriteString(msg)
+	writeKV(&sb, s.values)
+	writeKV(&sb, keysAndValues)
+	return sb.String()
+}
+
+func writeKV(sb *strings.Builder, keysAndValues []interface{}) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var v interface{} = "<missing>"
+		if i+1 < len(keysAndValues) {
+			v = keysAndValues[i+1]
+		}
+		fmt.Fprintf(sb, " %v=%v", keysAndValues[i], v)
+	}
+}
@@ -0,0 +1,61 @@
+//go:build dloglogr && !nodebug
+// +build dloglogr,!nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestLogger_Logr(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		debug        bool
+		fn           func(lr logr.Logger)
+		wantOutputRe string
+	}{
+		{"info",
+			false,
+			func(lr logr.Logger) { lr.Info("hello", "a", 1, "b", "two") },
+			`^logr_test\.go:\d+: hello a=1 b=two$`,
+		},
+		{"name and values",
+			false,
+			func(lr logr.Logger) { lr.WithName("ctrl").WithName("pod").WithValues("ns", "x").Info("hello", "odd") },
+			`^logr_test\.go:\d+: ctrl/pod: hello ns=x odd=<missing>$`,
+		},
+		{"error",
+			false,
+			func(lr logr.Logger) { lr.Error(errors.New("test error"), "failed", "a", 1) },
+			`^logr_test\.go:\d+: failed a=1 error=test error$`,
+		},
+		{"verbose, debug is off",
+			false,
+			func(lr logr.Logger) { lr.V(1).Info("hello") },
+			`^$`,
+		},
+		{"verbose, debug is on",
+			true,
+			func(lr logr.Logger) { lr.V(1).Info("hello") },
+			`^logr_test\.go:\d+: hello$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", log.Lshortfile, tt.debug)
+			tt.fn(l.Logr())
+
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package dlog
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// netQueueSize is the number of lines buffered by the network writer.
+	netQueueSize = 1024
+	// netMinBackoff and netMaxBackoff are the bounds of the delay between
+	// the reconnection attempts.
+	netMinBackoff = 100 * time.Millisecond
+	netMaxBackoff = 30 * time.Second
+	// netDialTimeout is the connection timeout.
+	netDialTimeout = 5 * time.Second
+	// netWriteTimeout is the timeout of sending a line, if the collector
+	// stops reading, the line is dropped and the writer reconnects.
+	netWriteTimeout = 5 * time.Second
+	// netCloseTimeout limits the time Close waits for the queue to drain.
+	netCloseTimeout = 5 * time.Second
+)
+
+// NewNetwork creates a new Logger that sends the output lines to the remote
+// collector at addr.  The network must be "tcp" or "udp".  Lines are queued
+// and sent in the background.  If the connection drops, the writer
+// reconnects with an exponential backoff; the lines that can't be sent in the
+// meantime, or that don't fit in the queue, are dropped and counted, see
+// Dropped.  It returns an error if the initial connection fails.  Close the
+// logger to flush the queue and close the connection.
+func NewNetwork(network, addr string, debug bool) (*Logger, error) {
+	switch network {
+	case "tcp", "udp":
+	default:
+		return nil, fmt.Errorf("unsupported network: %q", network)
+	}
+	w, err := newNetWriter(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return New(w, "", log.LstdFlags, debug), nil
+}
+
+// Dropped returns the number of lines dropped by the logger output, if the
+// output counts them, such as the one of NewNetwork or ChannelWriter.  It
+// returns 0 otherwise.
+func (l *Logger) Dropped() uint64 {
+	l = l.orStd()
+	if d, ok := l.Writer().(interface{ Dropped() uint64 }); ok {
+		return d.Dropped()
+	}
+	return 0
+}
+
+// netWriter is the io.Writer that sends lines over the network connection.
+type netWriter struct {
+	dropped uint64 // first for 64-bit alignment of atomics.
+
+	network string
+	addr    string
+	aborted int32 // set by Close on timeout, accessed atomically.
+
+	connMu sync.Mutex // guards conn, which is written by run only.
+	conn   net.Conn
+
+	mu     sync.Mutex // guards closed and sending on queue.
+	closed bool
+	queue  chan []byte
+	done   chan struct{}
+}
+
+var (
+	errClosed       = errors.New("writer is closed")
+	errCloseTimeout = errors.New("timed out sending the queued lines")
+)
+
+func newNetWriter(network, addr string) (*netWriter, error) {
+	conn, err := net.DialTimeout(network, addr, netDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	w := &netWriter{
+		network: network,
+		addr:    addr,
+		conn:    conn,
+		queue:   make(chan []byte, netQueueSize),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write queues p for sending.  If the queue is full, p is dropped.
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errClosed
+	}
+	line := make([]byte, len(p))
+	copy(line, p)
+	select {
+	case w.queue <- line:
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of dropped lines.
+func (w *netWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Close sends the queued lines and closes the connection.  If the lines
+// can't be sent within netCloseTimeout, the connection is closed, the rest of
+// the lines is dropped and an error is returned.
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+		return nil
+	case <-time.After(netCloseTimeout):
+	}
+	atomic.StoreInt32(&w.aborted, 1)
+	w.connMu.Lock()
+	if w.conn != nil {
+		w.conn.Close() // unblocks the pending write
+	}
+	w.connMu.Unlock()
+	<-w.done
+	return errCloseTimeout
+}
+
+func (w *netWriter) setConn(conn net.Conn) {
+	w.connMu.Lock()
+	w.conn = conn
+	w.connMu.Unlock()
+}
+
+func (w *netWriter) run() {
+	defer close(w.done)
+	var (
+		backoff = netMinBackoff
+		retryAt time.Time
+	)
+	for line := range w.queue {
+		if atomic.LoadInt32(&w.aborted) == 1 {
+			atomic.AddUint64(&w.dropped, 1)
+			continue
+		}
+		if w.conn == nil {
+			if time.Now().Before(retryAt) {
+				atomic.AddUint64(&w.dropped, 1)
+				continue
+			}
+			conn, err := net.DialTimeout(w.network, w.addr, netDialTimeout)
+			if err != nil {
+				atomic.AddUint64(&w.dropped, 1)
+				retryAt = time.Now().Add(backoff)
+				if backoff *= 2; backoff > netMaxBackoff {
+					backoff = netMaxBackoff
+				}
+				continue
+			}
+			w.setConn(conn)
+		}
+		w.conn.SetWriteDeadline(time.Now().Add(netWriteTimeout))
+		if _, err := w.conn.Write(line); err != nil {
+			// includes the timeout, when the collector stopped reading.
+			atomic.AddUint64(&w.dropped, 1)
+			w.conn.Close()
+			w.setConn(nil)
+			retryAt = time.Now().Add(backoff)
+			continue
+		}
+		backoff = netMinBackoff
+	}
+	if w.conn != nil {
+		w.conn.Close()
+	}
+}
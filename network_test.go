@@ -0,0 +1,165 @@
+package dlog
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewNetwork(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	lines := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+
+	l, err := NewNetwork("tcp", ln.Addr().String(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetFlags(0)
+	l.Print("one")
+	l.Print("two")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	if len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("want lines: [one two], got: %q", got)
+	}
+	if d := l.Dropped(); d != 0 {
+		t.Errorf("want no dropped lines, got: %d", d)
+	}
+}
+
+func TestNewNetwork_errors(t *testing.T) {
+	if _, err := NewNetwork("unix", "/tmp/sock", false); err == nil {
+		t.Error("want error for unsupported network")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if _, err := NewNetwork("tcp", addr, false); err == nil {
+		t.Error("want error for unreachable address")
+	}
+}
+
+func TestNewNetwork_reconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	l, err := NewNetwork("tcp", ln.Addr().String(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.SetFlags(0)
+
+	// accept and drop the first connection.
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	reconnected := make(chan *bufio.Scanner)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		reconnected <- bufio.NewScanner(conn)
+	}()
+
+	// keep writing until the writer notices the broken connection,
+	// drops a line and connects again.
+	deadline := time.After(5 * time.Second)
+	for {
+		l.Print("ping")
+		select {
+		case sc := <-reconnected:
+			if !sc.Scan() || sc.Text() != "ping" {
+				t.Errorf("want a line after reconnection, got: %q (%v)", sc.Text(), sc.Err())
+			}
+			if l.Dropped() == 0 {
+				t.Error("want dropped lines during the outage")
+			}
+			return
+		case <-deadline:
+			t.Fatal("writer did not reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestNewNetwork_stalledPeer(t *testing.T) {
+	defer func(wt, ct time.Duration) { netWriteTimeout, netCloseTimeout = wt, ct }(netWriteTimeout, netCloseTimeout)
+	netWriteTimeout, netCloseTimeout = 100*time.Millisecond, 200*time.Millisecond
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		// accept, and never read.
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	l, err := NewNetwork("tcp", ln.Addr().String(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetFlags(0)
+	line := strings.Repeat("x", 64<<10)
+	for i := 0; i < 512; i++ {
+		l.Print(line)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- l.Close()
+		l.Print("after close") // must not deadlock
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Close is blocked by the stalled peer")
+	}
+	if l.Dropped() == 0 {
+		t.Error("want dropped lines")
+	}
+}
@@ -0,0 +1,57 @@
+package dlog
+
+import "strings"
+
+// op is an entry in the operation stack.
+type op struct {
+	id   uint64
+	name string
+}
+
+// Enter pushes the operation name onto the logger operation stack and
+// returns the function that pops it, intended to be deferred:
+//
+//	defer l.Enter("load")()
+//
+// While there are operations on the stack, the output lines are suffixed
+// with the operations from outermost to innermost, i.e. "op=sync>load".  The
+// stack is shared by all users of the logger, so it is most useful with a
+// logger dedicated to a single task.
+func (l *Logger) Enter(name string) func() {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.opID++
+	id := l.opID
+	l.ops = append(l.ops, op{id: id, name: name})
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i := len(l.ops) - 1; i >= 0; i-- {
+			if l.ops[i].id == id {
+				l.ops = append(l.ops[:i], l.ops[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Enter pushes the operation name onto the standard logger operation stack
+// and returns the function that pops it.
+func Enter(name string) func() {
+	return std.Enter(name)
+}
+
+// opsSuffix returns the operation stack suffix for the line, l.mu must be
+// held.
+func (l *Logger) opsSuffix() string {
+	var sb strings.Builder
+	sb.WriteString(" op=")
+	for i, o := range l.ops {
+		if i > 0 {
+			sb.WriteByte('>')
+		}
+		sb.WriteString(o.name)
+	}
+	return sb.String()
+}
@@ -0,0 +1,51 @@
+package dlog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogger_Enter(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+
+	l.Print("start")
+	func() {
+		defer l.Enter("sync")()
+		l.Print("syncing")
+		func() {
+			defer l.Enter("load")()
+			l.Println("loading")
+		}()
+		l.Print("synced")
+	}()
+	l.Print("done")
+
+	want := "start\n" +
+		"syncing op=sync\n" +
+		"loading op=sync>load\n" +
+		"synced op=sync\n" +
+		"done\n"
+	if buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
+
+func TestLogger_Enter_outOfOrder(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+
+	popA := l.Enter("a")
+	popB := l.Enter("b")
+	popA()
+	l.Print("x")
+	popB()
+	popB() // no-op
+	l.Print("y")
+
+	if want := "x op=b\ny\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
@@ -0,0 +1,56 @@
+package dlog
+
+import "io"
+
+// Option is a logger setting, that can be applied with Apply.
+type Option func(l *Logger)
+
+// WithFlags sets the output flags.
+func WithFlags(flag int) Option {
+	return func(l *Logger) {
+		l.Logger.SetFlags(flag)
+	}
+}
+
+// WithPrefix sets the output prefix.
+func WithPrefix(prefix string) Option {
+	return func(l *Logger) {
+		l.Logger.SetPrefix(prefix)
+	}
+}
+
+// WithDebug enables or disables the debug output.  Same as SetDebug, it
+// adds log.Lshortfile to the flags when enabling.
+func WithDebug(b bool) Option {
+	return func(l *Logger) {
+		l.setDebug(b)
+	}
+}
+
+// WithWriter sets the output destination.  It is not called WithOutput, as
+// that name is taken by the function that redirects the output temporarily.
+func WithWriter(w io.Writer) Option {
+	return func(l *Logger) {
+		l.setOutput(w)
+	}
+}
+
+// Apply applies the options to the logger in the given order, while holding
+// the logger lock, so that the other goroutines never log with only part of
+// them applied.
+func (l *Logger) Apply(opts ...Option) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, opt := range opts {
+		opt(l)
+	}
+}
+
+// Apply applies the options to the standard logger.
+func Apply(opts ...Option) {
+	std.Apply(opts...)
+}
@@ -0,0 +1,43 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+)
+
+func TestLogger_Apply(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantOutputRe string
+	}{
+		{"no options", nil, `^old: message$`},
+		{"prefix", []Option{WithPrefix("new: ")}, `^new: message$`},
+		{"flags", []Option{WithFlags(log.Lshortfile)}, `^old: option_test\.go:\d+: message$`},
+		{"debug", []Option{WithFlags(0), WithDebug(true)}, `^old: option_test\.go:\d+: message\nold: option_test\.go:\d+: debug$`},
+		{"order", []Option{WithDebug(true), WithFlags(0)}, `^old: message\nold: debug$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var old, buf bytes.Buffer
+			l := New(&old, "old: ", 0, false)
+			l.Apply(append([]Option{WithWriter(&buf)}, tt.opts...)...)
+
+			l.Print("message")
+			l.Debug("debug")
+
+			if old.Len() != 0 {
+				t.Errorf("unexpected output to the old writer: %q", old.String())
+			}
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package dlog
+
+import (
+	"io"
+	"sync"
+)
+
+// pipeBuffer is the number of writes buffered for a slow pipe reader.
+const pipeBuffer = 1024
+
+// teeOutput is the logger output, that copies all writes to the attached
+// pipes.
+type teeOutput struct {
+	mu    sync.Mutex
+	out   io.Writer // the actual output
+	pipes []*logPipe
+}
+
+func (t *teeOutput) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, lp := range t.pipes {
+		lp.send(p)
+	}
+	return t.out.Write(p)
+}
+
+func (t *teeOutput) writer() io.Writer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out
+}
+
+func (t *teeOutput) setOutput(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.out = w
+}
+
+// remove detaches lp and returns the number of the pipes left.
+func (t *teeOutput) remove(lp *logPipe) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.pipes {
+		if t.pipes[i] == lp {
+			t.pipes = append(t.pipes[:i], t.pipes[i+1:]...)
+			break
+		}
+	}
+	return len(t.pipes)
+}
+
+// logPipe is the buffered connection between the logger output and the
+// pipe reader.
+type logPipe struct {
+	ch chan []byte
+	w  *io.PipeWriter
+}
+
+func newLogPipe() (*logPipe, *io.PipeReader) {
+	pr, pw := io.Pipe()
+	lp := &logPipe{ch: make(chan []byte, pipeBuffer), w: pw}
+	go lp.run()
+	return lp, pr
+}
+
+// send queues a copy of p, or drops it if the buffer is full.
+func (lp *logPipe) send(p []byte) {
+	select {
+	case lp.ch <- append([]byte(nil), p...):
+	default:
+	}
+}
+
+func (lp *logPipe) run() {
+	for p := range lp.ch {
+		// errors mean that the reader is closed, the rest is discarded.
+		lp.w.Write(p)
+	}
+	lp.w.Close()
+}
+
+// Pipe returns a reader, that receives a copy of everything written to the
+// logger output, and the function that detaches it.  It can be used to
+// show the log in the program itself, i.e. in a log viewer.
+//
+// The logger is never blocked by a slow reader: up to 1024 writes are
+// buffered, and the rest is dropped until the reader catches up.  After the
+// detach function is called, the reader returns the buffered lines followed
+// by io.EOF.  The reader should be read until io.EOF or closed, otherwise
+// the goroutine that feeds it is leaked.
+func (l *Logger) Pipe() (io.Reader, func()) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	lp, pr := newLogPipe()
+	l.mu.Lock()
+	if l.tee == nil {
+		tee := &teeOutput{out: l.writer()}
+		l.setOutput(tee)
+		l.tee = tee
+	}
+	tee := l.tee
+	tee.mu.Lock()
+	tee.pipes = append(tee.pipes, lp)
+	tee.mu.Unlock()
+	l.mu.Unlock()
+
+	var once sync.Once
+	return pr, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			if tee.remove(lp) == 0 && l.tee == tee {
+				l.tee = nil
+				l.setOutput(tee.writer())
+			}
+			l.mu.Unlock()
+			close(lp.ch)
+		})
+	}
+}
+
+// Pipe returns a reader, that receives a copy of the standard logger output,
+// and the function that detaches it.
+func Pipe() (io.Reader, func()) {
+	return std.Pipe()
+}
@@ -0,0 +1,83 @@
+package dlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestLogger_Pipe(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+
+	l.Print("before")
+	r, detach := l.Pipe()
+	if l.Writer() != &buf {
+		t.Error("Writer returned the pipe output")
+	}
+	l.Print("one")
+	l.Print("two")
+	detach()
+	detach() // second call is a no-op
+	l.Print("after")
+
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "one\ntwo\n"; string(got) != want {
+		t.Errorf("want pipe output: %q, got: %q", want, got)
+	}
+	if want := "before\none\ntwo\nafter\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+	if _, ok := l.Logger.Writer().(*teeOutput); ok {
+		t.Error("output is not restored after detach")
+	}
+}
+
+func TestLogger_Pipe_slowReader(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+
+	r, detach := l.Pipe()
+	// nobody reads, the logger must not block.
+	for i := 0; i < pipeBuffer*2; i++ {
+		l.Print("line")
+	}
+	detach()
+	if n := bytes.Count(buf.Bytes(), []byte("\n")); n != pipeBuffer*2 {
+		t.Errorf("want %d lines in the output, got %d", pipeBuffer*2, n)
+	}
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(got, []byte("\n")); n == 0 || n > pipeBuffer+1 {
+		t.Errorf("unexpected number of lines in the pipe: %d", n)
+	}
+}
+
+func TestLogger_Pipe_setOutput(t *testing.T) {
+	t.Parallel()
+	var first, second bytes.Buffer
+	l := New(&first, "", 0, false)
+
+	r, detach := l.Pipe()
+	l.SetOutput(&second)
+	l.Print("line")
+	detach()
+
+	got, _ := ioutil.ReadAll(r)
+	if want := "line\n"; string(got) != want {
+		t.Errorf("want pipe output: %q, got: %q", want, got)
+	}
+	if first.Len() != 0 || second.String() != "line\n" {
+		t.Errorf("unexpected outputs: first=%q second=%q", first.String(), second.String())
+	}
+	if l.Writer() != &second {
+		t.Error("detach reverted SetOutput")
+	}
+}
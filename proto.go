@@ -0,0 +1,32 @@
+//go:build dlogproto
+// +build dlogproto
+
+package dlog
+
+import (
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// DebugProto logs the protobuf message in the compact text format, if the
+// debug output is enabled.  When it's disabled, the message is not formatted.
+//
+// It is only available when building with the "dlogproto" build tag, so
+// that the protobuf dependency is not imposed on everyone.
+func (l *Logger) DebugProto(msg proto.Message) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, prototext.MarshalOptions{}.Format(msg))
+	}
+}
+
+// DebugProto logs the protobuf message to the standard logger, if the debug
+// output is enabled.
+func DebugProto(msg proto.Message) {
+	if std.IsDebug() {
+		std.debugOutput(2, prototext.MarshalOptions{}.Format(msg))
+	}
+}
@@ -0,0 +1,35 @@
+//go:build dlogproto && !nodebug
+// +build dlogproto,!nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestLogger_DebugProto(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		debug        bool
+		wantOutputRe string
+	}{
+		{"debug is on", true, `^proto_test\.go:\d+: value:\s*"hello"$`},
+		{"debug is off", false, `^$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, tt.debug)
+			l.DebugProto(wrapperspb.String("hello"))
+
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package dlog
+
+import (
+	"strconv"
+	"time"
+)
+
+// rateReportInterval is the minimum interval between the rate limiter
+// reports of the dropped lines.
+const rateReportInterval = time.Second
+
+// rateLimiter is the token bucket limiter of the output lines.
+type rateLimiter struct {
+	rate       float64 // tokens per second, also the bucket size.
+	tokens     float64
+	last       time.Time // time of the last refill.
+	dropped    int       // lines dropped since the last report.
+	lastReport time.Time
+}
+
+func newRateLimiter(linesPerSecond int, now time.Time) *rateLimiter {
+	return &rateLimiter{
+		rate:       float64(linesPerSecond),
+		tokens:     float64(linesPerSecond),
+		last:       now,
+		lastReport: now,
+	}
+}
+
+// allow returns true if the line can be written at the time now.  If it can,
+// and there were lines dropped, it also returns the report of dropped lines,
+// at most once in rateReportInterval.
+func (r *rateLimiter) allow(now time.Time) (ok bool, report string) {
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.rate {
+		r.tokens = r.rate
+	}
+	r.last = now
+	if r.tokens < 1 {
+		r.dropped++
+		return false, ""
+	}
+	r.tokens--
+	if r.dropped > 0 && now.Sub(r.lastReport) >= rateReportInterval {
+		report = "rate limited: dropped " + strconv.Itoa(r.dropped) + " lines"
+		r.dropped = 0
+		r.lastReport = now
+	}
+	return true, report
+}
+
+// SetRateLimit limits the output to linesPerSecond lines per second, with
+// bursts of up to linesPerSecond lines.  Lines exceeding the limit are
+// dropped; the number of dropped lines is reported in a separate line
+// before the next written line, at most once a second.  Fatal* and Panic*
+// lines are never dropped.  Zero or negative linesPerSecond disables the
+// limit, which is the default.
+func (l *Logger) SetRateLimit(linesPerSecond int) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if linesPerSecond <= 0 {
+		l.limiter = nil
+		return
+	}
+	l.limiter = newRateLimiter(linesPerSecond, time.Now())
+}
+
+// SetRateLimit limits the standard logger output to linesPerSecond lines per
+// second.
+func SetRateLimit(linesPerSecond int) {
+	std.SetRateLimit(linesPerSecond)
+}
@@ -0,0 +1,61 @@
+package dlog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiter(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newRateLimiter(2, start)
+
+	steps := []struct {
+		at         time.Duration
+		wantOK     bool
+		wantReport string
+	}{
+		{0, true, ""},
+		{0, true, ""},
+		{0, false, ""},                      // bucket is empty
+		{100 * time.Millisecond, false, ""}, // 0.2 tokens
+		{500 * time.Millisecond, true, ""},  // 1 token, report is too early
+		{1500 * time.Millisecond, true, "rate limited: dropped 2 lines"},
+		{1500 * time.Millisecond, true, ""},
+		{10 * time.Second, true, ""}, // bucket is capped at 2
+		{10 * time.Second, true, ""},
+		{10 * time.Second, false, ""},
+	}
+	for i, s := range steps {
+		ok, report := r.allow(start.Add(s.at))
+		if ok != s.wantOK || report != s.wantReport {
+			t.Errorf("step %d: allow() = %v, %q, want %v, %q", i, ok, report, s.wantOK, s.wantReport)
+		}
+	}
+}
+
+func TestLogger_SetRateLimit(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	l.SetRateLimit(2)
+	for i := 0; i < 5; i++ {
+		l.Print("line")
+	}
+	func() {
+		defer func() { recover() }()
+		l.Panic("panic")
+	}()
+	if want := "line\nline\npanic\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+
+	buf.Reset()
+	l.SetRateLimit(0)
+	for i := 0; i < 5; i++ {
+		l.Print("line")
+	}
+	if want := "line\nline\nline\nline\nline\n"; buf.String() != want {
+		t.Errorf("want output without limit: %q, got: %q", want, buf.String())
+	}
+}
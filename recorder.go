@@ -0,0 +1,48 @@
+package dlog
+
+import (
+	"strings"
+	"sync"
+)
+
+// Recorder records the lines written by a logger, for the assertions in
+// tests.
+type Recorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// NewRecorder returns a new Logger that writes to the returned Recorder.  The
+// logger has no prefix and no flags, so the recorded lines are just the
+// messages.
+func NewRecorder(debug bool) (*Logger, *Recorder) {
+	r := new(Recorder)
+	l := New(FuncWriter(r.add), "", 0, debug)
+	l.SetFlags(0) // SetDebug adds Lshortfile.
+	return l, r
+}
+
+func (r *Recorder) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, line)
+}
+
+// Lines returns the copy of the recorded lines.
+func (r *Recorder) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+// Contains returns true if any of the recorded lines contains substr.
+func (r *Recorder) Contains(substr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range r.lines {
+		if strings.Contains(line, substr) {
+			return true
+		}
+	}
+	return false
+}
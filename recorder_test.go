@@ -0,0 +1,29 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewRecorder(t *testing.T) {
+	t.Parallel()
+	l, r := NewRecorder(false)
+	l.Print("one")
+	l.Debug("hidden")
+	l.SetDebug(true)
+	l.SetFlags(0)
+	l.Debugf("two %d", 2)
+
+	if want := []string{"one", "two 2"}; !reflect.DeepEqual(r.Lines(), want) {
+		t.Errorf("want lines: %q, got: %q", want, r.Lines())
+	}
+	if !r.Contains("two") {
+		t.Error("Contains(two) = false, want true")
+	}
+	if r.Contains("hidden") {
+		t.Error("Contains(hidden) = true, want false")
+	}
+}
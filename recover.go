@@ -0,0 +1,67 @@
+package dlog
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Recover recovers from a panic and logs the panic value with the stack
+// trace, so that the goroutine continues.  It must be deferred directly,
+// otherwise recover has no effect and the panic continues:
+//
+//	defer l.Recover()
+//
+// The file and line of the line point to the panicking function.  It
+// returns the recovered value, or nil if there was no panic, but the value
+// is discarded when Recover is deferred directly, and Recover does not
+// recover when called inside a deferred closure.  To act on the panic value,
+// recover in the deferred closure and pass the value to LogPanic.  The
+// deferred mode buffer is written out, the same way as by Panic.
+func (l *Logger) Recover() interface{} {
+	r := recover()
+	if r != nil {
+		l = l.orStd()
+		l.release()
+		l.criticalOutput(3, recoverMessage(r))
+	}
+	return r
+}
+
+// Recover recovers from a panic and logs it to the standard logger.  It must
+// be deferred directly.
+func Recover() interface{} {
+	r := recover()
+	if r != nil {
+		std.release()
+		std.criticalOutput(3, recoverMessage(r))
+	}
+	return r
+}
+
+// LogPanic logs the recovered panic value r with the stack trace.  It is
+// intended for the deferred closures, that need the panic value:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			l.LogPanic(r)
+//			// handle r
+//		}
+//	}()
+//
+// The deferred mode buffer is written out, the same way as by Panic.
+func (l *Logger) LogPanic(r interface{}) {
+	l = l.orStd()
+	l.release()
+	l.criticalOutput(2, recoverMessage(r))
+}
+
+// LogPanic logs the recovered panic value r with the stack trace to the
+// standard logger.
+func LogPanic(r interface{}) {
+	std.release()
+	std.criticalOutput(2, recoverMessage(r))
+}
+
+func recoverMessage(r interface{}) string {
+	return fmt.Sprintf("panic: %v\n%s", r, debug.Stack())
+}
@@ -0,0 +1,94 @@
+package dlog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+)
+
+func TestLogger_Recover(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", log.Lshortfile, false)
+
+	func() {
+		defer l.Recover()
+		panic("boom")
+	}()
+
+	re := regexp.MustCompile(`(?s)^recover_test\.go:\d+: panic: boom\ngoroutine \d+ .*recover_test\.go:\d+`)
+	if !re.Match(buf.Bytes()) {
+		t.Errorf("output mismatch: wantRE: %q, got: %q", re, buf.String())
+	}
+
+	buf.Reset()
+	func() {
+		defer l.Recover()
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output without panic: %q", buf.String())
+	}
+}
+
+func TestLogger_Recover_nil(t *testing.T) {
+	defer Reset()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetFlags(0)
+
+	var l *Logger
+	func() {
+		defer l.Recover()
+		panic("boom")
+	}()
+	if !bytes.HasPrefix(buf.Bytes(), []byte("panic: boom\n")) {
+		t.Errorf("panic is not logged to the standard logger: %q", buf.String())
+	}
+}
+
+func TestLogger_LogPanic(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", log.Lshortfile, false)
+
+	var recovered interface{}
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				recovered = r
+				l.LogPanic(r)
+			}
+		}()
+		panic("boom")
+	}()
+
+	if recovered != "boom" {
+		t.Errorf("want recovered value: boom, got: %v", recovered)
+	}
+	re := regexp.MustCompile(`(?s)^recover_test\.go:\d+: panic: boom\ngoroutine \d+ .*recover_test\.go:\d+`)
+	if !re.Match(buf.Bytes()) {
+		t.Errorf("output mismatch: wantRE: %q, got: %q", re, buf.String())
+	}
+}
+
+func TestLogger_LogPanic_deferred(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	l.DeferredMode(true)
+	l.Print("before")
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				l.LogPanic(r)
+			}
+		}()
+		panic("boom")
+	}()
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte("before\npanic: boom\n")) {
+		t.Errorf("deferred lines are not written out: %q", buf.String())
+	}
+}
@@ -0,0 +1,184 @@
+package dlog
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"sync/atomic"
+)
+
+// LoggerState is the snapshot of the logger configuration, returned by
+// Snapshot.
+type LoggerState struct {
+	prefix   string
+	flags    int
+	output   io.Writer
+	dbgOut   io.Writer
+	debug    bool
+	goid     bool
+	useSeq   bool
+	panicPx  bool
+	escapeNL bool
+	pipeline []transform
+
+	keepNL      bool
+	noSerialize bool
+	skipPkgs    map[string]bool
+	limiter     *rateLimiter
+	panicFmt    func(v ...interface{}) string
+}
+
+// Snapshot returns the current configuration of the logger: prefix, flags,
+// output, debug mode, the line options, the skipped caller packages, the rate
+// limit and the panic formatter.  It can be restored later with Restore.  The
+// rate limiter is shared with the logger, not copied.
+func (l *Logger) Snapshot() LoggerState {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var dbgOut io.Writer
+	if l.dbg != nil {
+		dbgOut = l.dbg.Writer()
+	}
+	return LoggerState{
+		prefix:   l.Logger.Prefix(),
+		flags:    l.Flags(),
+		output:   l.writer(),
+		dbgOut:   dbgOut,
+		debug:    atomic.LoadInt32(&l.debug) == 1,
+		goid:     l.goid,
+		useSeq:   l.useSeq,
+		panicPx:  l.panicPx,
+		escapeNL: l.escapeNL,
+		pipeline: l.pipeline,
+
+		keepNL:      l.keepNL,
+		noSerialize: l.noSerialize,
+		skipPkgs:    copySet(l.skipPkgs),
+		limiter:     l.limiter,
+		panicFmt:    l.panicFmt,
+	}
+}
+
+// Restore restores the logger configuration from the state s, returned by
+// Snapshot.
+func (l *Logger) Restore(s LoggerState) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Logger.SetPrefix(s.prefix)
+	l.Logger.SetFlags(s.flags)
+	l.setOutput(s.output)
+	l.dbg = nil
+	if s.dbgOut != nil {
+		l.dbg = log.New(s.dbgOut, "", 0)
+	}
+	atomic.StoreInt32(&l.debug, btoi(s.debug))
+	l.goid = s.goid
+	l.useSeq = s.useSeq
+	l.panicPx = s.panicPx
+	l.escapeNL = s.escapeNL
+	l.pipeline = s.pipeline[:len(s.pipeline):len(s.pipeline)]
+	l.keepNL = s.keepNL
+	l.noSerialize = s.noSerialize
+	l.skipPkgs = copySet(s.skipPkgs)
+	l.limiter = s.limiter
+	l.panicFmt = s.panicFmt
+}
+
+// copySet returns a copy of m, or nil if m is empty.
+func copySet(m map[string]bool) map[string]bool {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(map[string]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Snapshot returns the current configuration of the standard logger.
+func Snapshot() LoggerState {
+	return std.Snapshot()
+}
+
+// Restore restores the standard logger configuration from the state s.
+func Restore(s LoggerState) {
+	std.Restore(s)
+}
+
+// Equal returns true if l and other have the same configuration: prefix,
+// flags, output, debug mode, the line options and the skipped caller
+// packages.  The internal state, such as the sequence counter, is not
+// compared.  The loggers with a rate limit or a panic formatter are never
+// equal, as the functions and the limiter state can't be compared.  A nil
+// *Logger is only equal to nil, unlike the other methods it does not stand
+// for the standard logger.
+func (l *Logger) Equal(other *Logger) bool {
+	if l == other {
+		return true
+	}
+	if l == nil || other == nil {
+		return false
+	}
+	return l.Snapshot().equal(other.Snapshot())
+}
+
+func (s LoggerState) equal(o LoggerState) bool {
+	if s.prefix != o.prefix || s.flags != o.flags || s.debug != o.debug ||
+		s.goid != o.goid || s.useSeq != o.useSeq || s.panicPx != o.panicPx {
+		return false
+	}
+	if !sameWriter(s.output, o.output) || !sameWriter(s.dbgOut, o.dbgOut) {
+		return false
+	}
+	if s.escapeNL != o.escapeNL || s.keepNL != o.keepNL || s.noSerialize != o.noSerialize {
+		return false
+	}
+	if !reflect.DeepEqual(s.skipPkgs, o.skipPkgs) {
+		return false
+	}
+	if s.limiter != nil || o.limiter != nil || s.panicFmt != nil || o.panicFmt != nil {
+		// functions and the limiter state can't be compared.
+		return false
+	}
+	if len(s.pipeline) != len(o.pipeline) {
+		return false
+	}
+	for i, t := range s.pipeline {
+		switch {
+		case t.re != nil:
+			if o.pipeline[i].re == nil || t.re.String() != o.pipeline[i].re.String() {
+				return false
+			}
+		case t.escape:
+			if !o.pipeline[i].escape {
+				return false
+			}
+		default:
+			// functions can't be compared.
+			return false
+		}
+	}
+	return true
+}
+
+// sameWriter returns true if a and b are the same writer.  Unlike ==, it does
+// not panic on the writers of uncomparable types, which are considered
+// different.
+func sameWriter(a, b io.Writer) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,128 @@
+package dlog
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestLogger_SnapshotRestore(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "orig: ", log.LstdFlags, false)
+	l.AddFilter(regexp.MustCompile(`drop`))
+	l.SetCallerSkipPackages([]string{"example.com/wrapper"})
+	l.SetPanicFormatter(func(v ...interface{}) string { return "orig" })
+	s := l.Snapshot()
+
+	l.SetPrefix("temp: ")
+	l.SerializeWrites(false)
+	l.SetOutput(os.Stderr)
+	l.SetDebug(true)
+	l.SetIncludeSequence(true)
+	l.AddFilter(regexp.MustCompile(`keep`))
+	l.SetCallerSkipPackages([]string{"example.com/other"})
+	l.SetPanicFormatter(nil)
+	l.SetWriteTrimNewline(false)
+	l.SetRateLimit(1)
+
+	l.Restore(s)
+	if p := l.Prefix(); p != "orig: " {
+		t.Errorf("want prefix: %q, got: %q", "orig: ", p)
+	}
+	if f := l.Flags(); f != log.LstdFlags {
+		t.Errorf("want flags: %v, got: %v", log.LstdFlags, f)
+	}
+	if l.Writer() != &buf {
+		t.Errorf("output is not restored")
+	}
+	if l.IsDebug() {
+		t.Errorf("want debug: false, got: true")
+	}
+	if l.keepNL || l.noSerialize || l.limiter != nil {
+		t.Errorf("line options are not restored: keepNL=%v noSerialize=%v limiter=%v", l.keepNL, l.noSerialize, l.limiter)
+	}
+	if want := map[string]bool{"example.com/wrapper": true}; !reflect.DeepEqual(l.skipPkgs, want) {
+		t.Errorf("want skipped packages: %v, got: %v", want, l.skipPkgs)
+	}
+	if got := l.panicMessage("x"); got != "orig" {
+		t.Errorf("panic formatter is not restored, got message: %q", got)
+	}
+
+	l.SetFlags(0)
+	l.Print("keep")
+	l.Print("drop")
+	if want := "orig: keep\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
+
+// uncomparableWriter is a writer of an uncomparable type.
+type uncomparableWriter struct {
+	_ []byte
+}
+
+func (uncomparableWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// configured applies fn to l and returns it.
+func configured(l *Logger, fn func(l *Logger)) *Logger {
+	fn(l)
+	return l
+}
+
+func TestLogger_Equal(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	base := New(&buf, ">", log.LstdFlags, false)
+	tests := []struct {
+		name  string
+		other *Logger
+		want  bool
+	}{
+		{"same logger", base, true},
+		{"same config", New(&buf, ">", log.LstdFlags, false), true},
+		{"nil", nil, false},
+		{"different prefix", New(&buf, "<", log.LstdFlags, false), false},
+		{"different flags", New(&buf, ">", 0, false), false},
+		{"different output", New(os.Stderr, ">", log.LstdFlags, false), false},
+		{"different debug", New(&buf, ">", log.LstdFlags, true), false},
+		{"uncomparable output", New(uncomparableWriter{}, ">", log.LstdFlags, false), false},
+		{"keep newline", configured(New(&buf, ">", log.LstdFlags, false), func(l *Logger) { l.SetWriteTrimNewline(false) }), false},
+		{"not serialized", configured(New(&buf, ">", log.LstdFlags, false), func(l *Logger) { l.SerializeWrites(false) }), false},
+		{"skip packages", configured(New(&buf, ">", log.LstdFlags, false), func(l *Logger) { l.SetCallerSkipPackages([]string{"x"}) }), false},
+		{"rate limit", configured(New(&buf, ">", log.LstdFlags, false), func(l *Logger) { l.SetRateLimit(1) }), false},
+		{"panic formatter", configured(New(&buf, ">", log.LstdFlags, false), func(l *Logger) { l.SetPanicFormatter(fmt.Sprint) }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Equal(tt.other); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	u := New(uncomparableWriter{}, "", 0, false)
+	if u.Equal(New(uncomparableWriter{}, "", 0, false)) {
+		t.Error("loggers with uncomparable outputs must not be equal")
+	}
+	if !u.Equal(u) {
+		t.Error("logger must be equal to itself")
+	}
+	skip := New(&buf, "", 0, false)
+	skip.SetCallerSkipPackages([]string{"x", "y"})
+	skip2 := New(&buf, "", 0, false)
+	skip2.SetCallerSkipPackages([]string{"y", "x"})
+	if !skip.Equal(skip2) {
+		t.Error("loggers with the same skipped packages must be equal")
+	}
+	var nilLogger *Logger
+	if nilLogger.Equal(std) || std.Equal(nilLogger) {
+		t.Error("nil logger must not be equal to the standard logger")
+	}
+	if !nilLogger.Equal(nil) {
+		t.Error("nil logger must be equal to nil")
+	}
+}
@@ -0,0 +1,87 @@
+package dlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Debugt logs the template with {name} placeholders expanded from fields, if
+// the debug output is enabled.  Fields not referenced by the template are
+// appended to the message as key=value pairs, sorted by key.  Placeholders
+// that have no value in fields are left as is, and a warning listing them is
+// appended to the message.
+//
+//	l.Debugt("user {user} logged in", map[string]interface{}{"user": "bob", "ip": "10.0.0.1"})
+//
+// logs "user bob logged in ip=10.0.0.1".
+func (l *Logger) Debugt(template string, fields map[string]interface{}) {
+	l = l.orStd()
+	if l.Logger == nil {
+		l.Logger = defaultLogger()
+	}
+	if l.IsDebug() {
+		l.debugOutput(2, expand(template, fields))
+	}
+}
+
+// Debugt logs the expanded template to the standard logger, if the debug
+// output is enabled.
+func Debugt(template string, fields map[string]interface{}) {
+	if std.IsDebug() {
+		std.debugOutput(2, expand(template, fields))
+	}
+}
+
+// expand expands the {name} placeholders in template with the values from
+// fields and appends the unused fields and missing placeholders.
+func expand(template string, fields map[string]interface{}) string {
+	var (
+		sb      strings.Builder
+		used    = make(map[string]bool, len(fields))
+		missing []string
+	)
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		name := template[start+1 : end]
+		if name == "" || strings.ContainsAny(name, "{ ") {
+			// not a placeholder
+			sb.WriteString(template[:start+1])
+			template = template[start+1:]
+			continue
+		}
+		sb.WriteString(template[:start])
+		if v, ok := fields[name]; ok {
+			fmt.Fprint(&sb, v)
+			used[name] = true
+		} else {
+			sb.WriteString(template[start : end+1])
+			missing = append(missing, name)
+		}
+		template = template[end+1:]
+	}
+	sb.WriteString(template)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if !used[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%v", k, fields[k])
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(&sb, " warning=%q", "missing fields: "+strings.Join(missing, ", "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,25 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogger_Debugt(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	l.Debugt("hello {name}", map[string]interface{}{"name": "world"})
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output with debug off: %q", buf.String())
+	}
+	l.SetDebug(true)
+	l.SetFlags(0)
+	l.Debugt("hello {name}", map[string]interface{}{"name": "world"})
+	if want := "hello world\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
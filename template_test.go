@@ -0,0 +1,42 @@
+package dlog
+
+import (
+	"testing"
+)
+
+func Test_expand(t *testing.T) {
+	type args struct {
+		template string
+		fields   map[string]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"no placeholders", args{"hello", nil}, "hello"},
+		{"all used",
+			args{"user {user} logged in from {ip}", map[string]interface{}{"user": "bob", "ip": "10.0.0.1"}},
+			"user bob logged in from 10.0.0.1",
+		},
+		{"unused fields",
+			args{"user {user} logged in", map[string]interface{}{"user": "bob", "ip": "10.0.0.1", "attempt": 2}},
+			"user bob logged in attempt=2 ip=10.0.0.1",
+		},
+		{"missing placeholder",
+			args{"user {user} logged in", map[string]interface{}{}},
+			`user {user} logged in warning="missing fields: user"`,
+		},
+		{"not placeholders",
+			args{"{} { x } {{n}} {unterminated", map[string]interface{}{"n": 1}},
+			"{} { x } {1} {unterminated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expand(tt.args.template, tt.args.fields); got != tt.want {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package dlog
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// reservoirSize is the number of samples kept by LatencyTracker.
+const reservoirSize = 1024
+
+// LogIfSlow returns a function that, when called, logs a line if more than
+// threshold has elapsed since LogIfSlow was called.  It is intended to be
+// deferred:
+//
+//	defer l.LogIfSlow(100*time.Millisecond, "query")()
+//
+// Fast operations produce no output, so it is cheap to keep in production
+// code as a latency watchdog.
+func (l *Logger) LogIfSlow(threshold time.Duration, name string) func() {
+	l = l.orStd()
+	start := time.Now()
+	return func() {
+		if took := time.Since(start); took > threshold {
+			l.Output(2, fmt.Sprintf("slow: %s took %s (threshold %s)", name, took, threshold))
+		}
+	}
+}
+
+// LogIfSlow returns a function that logs to the standard logger if it's
+// called later than threshold after LogIfSlow.
+func LogIfSlow(threshold time.Duration, name string) func() {
+	return std.LogIfSlow(threshold, name)
+}
+
+// Around logs "entering name" at debug level, runs fn and then logs
+// "leaving name (err=<error>) took <duration>".  It returns the error
+// returned by fn.  When the debug output is disabled, it just runs fn.
+func (l *Logger) Around(name string, fn func() error) error {
+	l = l.orStd()
+	if !l.IsDebug() {
+		return fn()
+	}
+	l.debugOutput(2, "entering "+name)
+	start := time.Now()
+	err := fn()
+	l.debugOutput(2, fmt.Sprintf("leaving %s (err=%v) took %s", name, err, time.Since(start)))
+	return err
+}
+
+// Around runs fn, logging entering and leaving to the standard logger, if the
+// debug output is enabled.
+func Around(name string, fn func() error) error {
+	if !std.IsDebug() {
+		return fn()
+	}
+	std.debugOutput(2, "entering "+name)
+	start := time.Now()
+	err := fn()
+	std.debugOutput(2, fmt.Sprintf("leaving %s (err=%v) took %s", name, err, time.Since(start)))
+	return err
+}
+
+// LogRetry logs a failed attempt of an operation that is retried:
+//
+//	attempt 2/5 failed: <err>; retrying in 500ms
+//
+// On the last attempt, when attempt is max, it logs "attempt 5/5 failed:
+// <err>; giving up" instead.
+func (l *Logger) LogRetry(attempt, max int, err error, next time.Duration) {
+	l = l.orStd()
+	l.Output(2, retryMessage(attempt, max, err, next))
+}
+
+// LogRetry logs a failed attempt of an operation to the standard logger.
+func LogRetry(attempt, max int, err error, next time.Duration) {
+	std.Output(2, retryMessage(attempt, max, err, next))
+}
+
+func retryMessage(attempt, max int, err error, next time.Duration) string {
+	if attempt >= max {
+		return fmt.Sprintf("attempt %d/%d failed: %v; giving up", attempt, max, err)
+	}
+	return fmt.Sprintf("attempt %d/%d failed: %v; retrying in %s", attempt, max, err, next)
+}
+
+// Measure runs fn and logs the timing record of it:
+//
+//	op=name duration_ms=12.345 error="connection refused"
+//
+// If fn returns an error, the record is logged to the output, otherwise
+// it's logged to the debug output, without the error field.  It returns the
+// error returned by fn.
+func (l *Logger) Measure(name string, fn func() error) error {
+	l = l.orStd()
+	start := time.Now()
+	err := fn()
+	if err != nil {
+		l.Output(2, measureMessage(name, time.Since(start), err))
+	} else if l.IsDebug() {
+		l.debugOutput(2, measureMessage(name, time.Since(start), nil))
+	}
+	return err
+}
+
+// Measure runs fn and logs the timing record of it to the standard logger.
+func Measure(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	if err != nil {
+		std.Output(2, measureMessage(name, time.Since(start), err))
+	} else if std.IsDebug() {
+		std.debugOutput(2, measureMessage(name, time.Since(start), nil))
+	}
+	return err
+}
+
+func measureMessage(name string, took time.Duration, err error) string {
+	s := fmt.Sprintf("op=%s duration_ms=%.3f", name, float64(took)/float64(time.Millisecond))
+	if err != nil {
+		s += fmt.Sprintf(" error=%q", err.Error())
+	}
+	return s
+}
+
+// LatencyTracker collects the durations of a repeated operation and reports
+// the percentiles.  It keeps a fixed size random sample of observations
+// (reservoir sampling), so that the memory use is constant.
+type LatencyTracker struct {
+	l    *Logger
+	name string
+
+	mu      sync.Mutex
+	count   int
+	samples []time.Duration
+	rnd     *rand.Rand
+}
+
+// NewLatencyTracker returns a new LatencyTracker that reports to the logger.
+func (l *Logger) NewLatencyTracker(name string) *LatencyTracker {
+	l = l.orStd()
+	return &LatencyTracker{
+		l:       l,
+		name:    name,
+		samples: make([]time.Duration, 0, reservoirSize),
+		rnd:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// NewLatencyTracker returns a new LatencyTracker that reports to the standard
+// logger.
+func NewLatencyTracker(name string) *LatencyTracker {
+	return std.NewLatencyTracker(name)
+}
+
+// Observe records the duration d.
+func (t *LatencyTracker) Observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count++
+	if len(t.samples) < reservoirSize {
+		t.samples = append(t.samples, d)
+		return
+	}
+	if i := t.rnd.Intn(t.count); i < reservoirSize {
+		t.samples[i] = d
+	}
+}
+
+// Report logs the number of observations and the 50th, 95th and 99th
+// percentiles of the durations observed so far.
+func (t *LatencyTracker) Report() {
+	t.l.Output(2, t.String())
+}
+
+// String returns the report line.
+func (t *LatencyTracker) String() string {
+	t.mu.Lock()
+	sorted := make([]time.Duration, len(t.samples))
+	copy(sorted, t.samples)
+	count := t.count
+	t.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return fmt.Sprintf("latency %s: count=%d p50=%s p95=%s p99=%s",
+		t.name, count, percentile(sorted, 50), percentile(sorted, 95), percentile(sorted, 99))
+}
+
+// percentile returns the p-th percentile of the sorted durations, using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Heartbeat logs msg every interval in a background goroutine, until ctx is
+// cancelled.  It can be used to show that a long running task is still
+// progressing.  If interval is not positive, Heartbeat does nothing.
+func (l *Logger) Heartbeat(ctx context.Context, interval time.Duration, msg string) {
+	l = l.orStd()
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				l.Output(1, msg)
+			}
+		}
+	}()
+}
+
+// Heartbeat logs msg to the standard logger every interval, until ctx is
+// cancelled.
+func Heartbeat(ctx context.Context, interval time.Duration, msg string) {
+	std.Heartbeat(ctx, interval, msg)
+}
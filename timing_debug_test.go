@@ -0,0 +1,86 @@
+//go:build !nodebug
+// +build !nodebug
+
+package dlog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestLogger_Measure(t *testing.T) {
+	t.Parallel()
+	testErr := errors.New("test error")
+	tests := []struct {
+		name         string
+		debug        bool
+		err          error
+		wantOutputRe string
+	}{
+		{"success, debug off", false, nil, `^$`},
+		{"success, debug on", true, nil, `^timing_debug_test\.go:\d+: op=query duration_ms=\d+\.\d{3}$`},
+		{"error, debug off", false, testErr, `^op=query duration_ms=\d+\.\d{3} error="test error"$`},
+		{"error, debug on", true, testErr, `^timing_debug_test\.go:\d+: op=query duration_ms=\d+\.\d{3} error="test error"$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, tt.debug)
+
+			err := l.Measure("query", func() error { return tt.err })
+
+			if err != tt.err {
+				t.Errorf("want error: %v, got: %v", tt.err, err)
+			}
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_Around(t *testing.T) {
+	t.Parallel()
+	errTest := errors.New("test error")
+	tests := []struct {
+		name         string
+		debug        bool
+		err          error
+		wantOutputRe string
+	}{
+		{"debug is on",
+			true,
+			nil,
+			`^timing_debug_test\.go:\d+: entering op\ntiming_debug_test\.go:\d+: leaving op \(err=<nil>\) took \S+$`,
+		},
+		{"debug is on, error",
+			true,
+			errTest,
+			`^timing_debug_test\.go:\d+: entering op\ntiming_debug_test\.go:\d+: leaving op \(err=test error\) took \S+$`,
+		},
+		{"debug is off", false, errTest, `^$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", 0, tt.debug)
+
+			var called bool
+			err := l.Around("op", func() error {
+				called = true
+				return tt.err
+			})
+			if !called {
+				t.Error("fn was not called")
+			}
+			if err != tt.err {
+				t.Errorf("want error: %v, got: %v", tt.err, err)
+			}
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package dlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestLogger_LogIfSlow(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		threshold    time.Duration
+		sleep        time.Duration
+		wantOutputRe string
+	}{
+		{"fast operation", time.Hour, 0, `^$`},
+		{"slow operation", time.Millisecond, 5 * time.Millisecond, `^timing_test\.go:\d+: slow: op took \S+ \(threshold 1ms\)$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", log.Lshortfile, false)
+
+			done := l.LogIfSlow(tt.threshold, "op")
+			time.Sleep(tt.sleep)
+			done()
+
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_LogRetry(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		attempt      int
+		max          int
+		wantOutputRe string
+	}{
+		{"retrying", 2, 5, `^timing_test\.go:\d+: attempt 2/5 failed: test error; retrying in 500ms$`},
+		{"last attempt", 5, 5, `^timing_test\.go:\d+: attempt 5/5 failed: test error; giving up$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(&buf, "", log.Lshortfile, false)
+
+			l.LogRetry(tt.attempt, tt.max, errors.New("test error"), 500*time.Millisecond)
+
+			if !regexp.MustCompile(tt.wantOutputRe).Match(bytes.TrimSpace(buf.Bytes())) {
+				t.Errorf("output mismatch: wantRE: %q, got: %q", tt.wantOutputRe, buf.String())
+			}
+		})
+	}
+}
+
+func TestLatencyTracker(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	lt := l.NewLatencyTracker("op")
+	for i := 1; i <= 100; i++ {
+		lt.Observe(time.Duration(i) * time.Millisecond)
+	}
+	lt.Report()
+	if want := "latency op: count=100 p50=50ms p95=95ms p99=99ms\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
+
+func TestLatencyTracker_bounded(t *testing.T) {
+	t.Parallel()
+	lt := New(&bytes.Buffer{}, "", 0, false).NewLatencyTracker("op")
+	for i := 0; i < 10*reservoirSize; i++ {
+		lt.Observe(time.Millisecond)
+	}
+	if len(lt.samples) != reservoirSize {
+		t.Errorf("want %d samples, got: %d", reservoirSize, len(lt.samples))
+	}
+	if lt.count != 10*reservoirSize {
+		t.Errorf("want count: %d, got: %d", 10*reservoirSize, lt.count)
+	}
+}
+
+func Test_percentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{0, 1}, {25, 1}, {50, 2}, {75, 3}, {99, 4}, {100, 4},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of empty = %v, want 0", got)
+	}
+}
+
+func TestLogger_Heartbeat(t *testing.T) {
+	t.Parallel()
+	lines := make(chan string, 100)
+	l := New(FuncWriter(func(line string) { lines <- line }), "", 0, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.Heartbeat(ctx, time.Millisecond, "alive")
+	for i := 0; i < 3; i++ {
+		select {
+		case line := <-lines:
+			if line != "alive" {
+				t.Errorf("want line: %q, got: %q", "alive", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no heartbeat")
+		}
+	}
+	cancel()
+
+	// allow for the tick that might have been in flight.
+	time.Sleep(20 * time.Millisecond)
+	for len(lines) > 0 {
+		<-lines
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(lines); n != 0 {
+		t.Errorf("heartbeat continues after cancel: %d lines", n)
+	}
+}
+
+func TestLogger_Heartbeat_invalidInterval(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, interval := range []time.Duration{0, -time.Second} {
+		l.Heartbeat(ctx, interval, "alive") // must not panic
+	}
+	time.Sleep(20 * time.Millisecond)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
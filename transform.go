@@ -0,0 +1,115 @@
+package dlog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// transform is a step of the message pipeline.  It is either a filter, that
+// drops the messages matching re, or a function that transforms the message.
+type transform struct {
+	re     *regexp.Regexp
+	fn     func(string) string
+	escape bool // fn is escapeNewlines
+}
+
+// AddTransform adds the function to the message pipeline of the logger.
+// Each message is passed through the pipeline before it is written.  The
+// pipeline steps are applied in the order they were added, this includes
+// the filters added with AddFilter and the newline escaping enabled with
+// SetEscapeNewlines, so that, for example:
+//
+//	l.AddTransform(redact)
+//	l.AddTransform(truncate)
+//	l.SetEscapeNewlines(true)
+//
+// redacts, then truncates and then escapes the newlines.  The functions
+// receive the message without the trailing newline and must be safe for
+// concurrent use.  The prefix, date, time and other decorations are added
+// after the pipeline.
+func (l *Logger) AddTransform(fn func(string) string) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pipeline = append(l.pipeline, transform{fn: fn})
+}
+
+// AddFilter adds a filter to the message pipeline of the logger.  Any
+// message matching the filter is dropped and not written to the output, the
+// filters are ORed together.  Only the message text is matched, as it is at
+// the filter's position in the pipeline: the prefix, date, time, file name
+// and other decorations are added after the pipeline, so the pattern can't
+// match them.
+func (l *Logger) AddFilter(pattern *regexp.Regexp) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pipeline = append(l.pipeline, transform{re: pattern})
+}
+
+// newlineEscaper replaces the line breaks with the escape sequences.
+var newlineEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+func escapeNewlines(s string) string {
+	return newlineEscaper.Replace(s)
+}
+
+// SetEscapeNewlines enables or disables escaping of the line breaks within
+// the message: "\n" and "\r" are replaced with the literal `\n` and `\r`, so
+// that each message stays on a single line, as expected by the line based
+// log parsers.  It is disabled by default.  Enabling it adds the escaping
+// step at the end of the message pipeline, see AddTransform.
+func (l *Logger) SetEscapeNewlines(b bool) {
+	l = l.orStd()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b == l.escapeNL {
+		return
+	}
+	l.escapeNL = b
+	if b {
+		l.pipeline = append(l.pipeline, transform{fn: escapeNewlines, escape: true})
+		return
+	}
+	pipeline := make([]transform, 0, len(l.pipeline)-1)
+	for _, t := range l.pipeline {
+		if !t.escape {
+			pipeline = append(pipeline, t)
+		}
+	}
+	l.pipeline = pipeline
+}
+
+// apply passes the message s through the pipeline.  It returns false if the
+// message is dropped.  l.mu must be held.
+func (l *Logger) apply(s string) (string, bool) {
+	s = strings.TrimSuffix(s, "\n")
+	for _, t := range l.pipeline {
+		if t.re != nil {
+			if t.re.MatchString(s) {
+				return "", false
+			}
+			continue
+		}
+		s = t.fn(s)
+	}
+	return s, true
+}
+
+// AddTransform adds the function to the message pipeline of the standard
+// logger.
+func AddTransform(fn func(string) string) {
+	std.AddTransform(fn)
+}
+
+// AddFilter adds a filter to the standard logger.  Messages matching any of
+// the filters are dropped, only the message text is matched.
+func AddFilter(pattern *regexp.Regexp) {
+	std.AddFilter(pattern)
+}
+
+// SetEscapeNewlines enables or disables escaping of the line breaks within
+// the standard logger messages.
+func SetEscapeNewlines(b bool) {
+	std.SetEscapeNewlines(b)
+}
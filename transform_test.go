@@ -0,0 +1,42 @@
+package dlog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLogger_AddTransform(t *testing.T) {
+	t.Parallel()
+	redact := func(s string) string { return strings.Replace(s, "secret", "******", -1) }
+	truncate := func(s string) string {
+		if len(s) > 20 {
+			return s[:20] + "..."
+		}
+		return s
+	}
+
+	var buf bytes.Buffer
+	l := New(&buf, "", 0, false)
+	l.AddTransform(redact)
+	l.AddFilter(regexp.MustCompile(`secret`)) // sees the redacted message
+	l.AddTransform(truncate)
+	l.SetEscapeNewlines(true)
+
+	l.Println("password: secret")
+	l.Print("a long message\nthat spans lines")
+
+	want := "password: ******\n" +
+		`a long message\nthat ...` + "\n"
+	if buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+
+	buf.Reset()
+	l.SetEscapeNewlines(false)
+	l.Print("short\nmessage")
+	if want := "short\nmessage\n"; buf.String() != want {
+		t.Errorf("want output: %q, got: %q", want, buf.String())
+	}
+}
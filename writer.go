@@ -0,0 +1,86 @@
+package dlog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// lineWriter is an io.Writer that calls fn for each complete line written to
+// it, without the trailing newline.  Partial lines are buffered until the
+// newline is written or until Flush is called.
+type lineWriter struct {
+	mu  sync.Mutex
+	buf []byte
+	fn  func(line string)
+}
+
+// FuncWriter returns an io.Writer that calls fn for each complete line
+// written to it, the line is passed without the trailing newline.  It can be
+// used as the logger output to send the log lines anywhere:
+//
+//	l := dlog.New(dlog.FuncWriter(func(line string) { lines = append(lines, line) }), "", 0, false)
+//
+// Partial lines are buffered until the newline is received.  The returned
+// writer has a Flush method that passes the incomplete line, if any, to fn.
+func FuncWriter(fn func(line string)) io.Writer {
+	return &lineWriter{fn: fn}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Flush passes the buffered incomplete line to the function.
+func (w *lineWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.fn(string(w.buf))
+		w.buf = nil
+	}
+	return nil
+}
+
+// chanWriter is the io.Writer that sends lines to a channel.
+type chanWriter struct {
+	dropped uint64 // first for 64-bit alignment of atomics.
+	lineWriter
+}
+
+// ChannelWriter returns an io.Writer that sends each complete line written to
+// it to ch, without the trailing newline.  If ch is full, the line is dropped,
+// so that the logger is never blocked.  The returned writer has a Dropped
+// method, that returns the number of dropped lines, and a Flush method, that
+// sends the incomplete line, if any.
+func ChannelWriter(ch chan<- string) io.Writer {
+	w := new(chanWriter)
+	w.fn = func(line string) {
+		select {
+		case ch <- line:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	}
+	return w
+}
+
+// Dropped returns the number of dropped lines.
+func (w *chanWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
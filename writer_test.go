@@ -0,0 +1,50 @@
+package dlog
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFuncWriter(t *testing.T) {
+	var lines []string
+	w := FuncWriter(func(line string) { lines = append(lines, line) })
+
+	for _, s := range []string{"one\ntw", "o\n", "\nthree", " and a half"} {
+		if _, err := io.WriteString(w, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"one", "two", ""}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("want lines: %q, got: %q", want, lines)
+	}
+	w.(interface{ Flush() error }).Flush()
+	if want := []string{"one", "two", "", "three and a half"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("after flush: want lines: %q, got: %q", want, lines)
+	}
+}
+
+func TestFuncWriter_logger(t *testing.T) {
+	var lines []string
+	l := New(FuncWriter(func(line string) { lines = append(lines, line) }), "> ", 0, false)
+	l.Print("hello")
+	l.Println("world")
+	if want := []string{"> hello", "> world"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("want lines: %q, got: %q", want, lines)
+	}
+}
+
+func TestChannelWriter(t *testing.T) {
+	ch := make(chan string, 2)
+	l := New(ChannelWriter(ch), "", 0, false)
+	l.Print("one")
+	l.Print("two")
+	l.Print("three")
+
+	if got := []string{<-ch, <-ch}; !reflect.DeepEqual(got, []string{"one", "two"}) {
+		t.Errorf("want lines: [one two], got: %q", got)
+	}
+	if d := l.Dropped(); d != 1 {
+		t.Errorf("want 1 dropped line, got: %d", d)
+	}
+}